@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hejmsdz/bb/model"
+	"github.com/hejmsdz/bb/prs"
+)
+
+type Action struct {
+	Key        string
+	Name       string
+	Command    string
+	Foreground bool
+	template   *template.Template
+}
+
+// actionContext holds the values available in command templates.
+// They are shell-quoted, so they must not be wrapped in quotes in the template.
+type actionContext struct {
+	Id           string
+	Repo         string
+	Branch       string
+	TargetBranch string
+	Title        string
+	Author       string
+	Url          string
+	LocalPath    string
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ParseActions compiles the command templates. The keys of the actions must not be taken by bb,
+// the saved filters or other actions, as those are handled first.
+func ParseActions(actions []Action, filters []model.SavedFilter) ([]Action, error) {
+	taken := make(map[string]string)
+	for _, filter := range filters {
+		taken[filter.Key] = "saved filter " + strconv.Quote(filter.Name)
+	}
+	parsed := make([]Action, 0, len(actions))
+	for _, action := range actions {
+		switch {
+		case action.Key == "":
+			return nil, fmt.Errorf("action %q has no key", action.Name)
+		case reservedKeys[action.Key]:
+			return nil, fmt.Errorf("action %q: the key %q is taken by bb", action.Name, action.Key)
+		case taken[action.Key] != "":
+			return nil, fmt.Errorf("action %q: the key %q is taken by %s", action.Name, action.Key, taken[action.Key])
+		}
+		taken[action.Key] = "action " + strconv.Quote(action.Name)
+		tmpl, err := template.New(action.Name).Option("missingkey=error").Parse(action.Command)
+		if err != nil {
+			return nil, fmt.Errorf("action %q: %w", action.Name, err)
+		}
+		action.template = tmpl
+		parsed = append(parsed, action)
+	}
+	return parsed, nil
+}
+
+func ActionKeyBindings(actions []Action) []key.Binding {
+	bindings := make([]key.Binding, 0, len(actions))
+	for _, action := range actions {
+		bindings = append(bindings, key.NewBinding(
+			key.WithKeys(action.Key),
+			key.WithHelp(action.Key, action.Name),
+		))
+	}
+	return bindings
+}
+
+// PrLocalPath returns the directory where the pull request branch can be found locally:
+// its worktree if one exists, otherwise the local copy of the repository.
+func PrLocalPath(pr prs.PullRequest, m rootModel) string {
+	if m.checkoutMode == checkoutModeWorktree {
+		path := WorktreePath(pr, m)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return m.localRepos[pr.Repo]
+}
+
+func actionCommand(action Action, pr prs.PullRequest, m rootModel) (*exec.Cmd, error) {
+	localPath := PrLocalPath(pr, m)
+	ctx := actionContext{
+		Id:           shellQuote(pr.Id),
+		Repo:         shellQuote(pr.Repo),
+		Branch:       shellQuote(pr.Branch),
+		TargetBranch: shellQuote(pr.TargetBranch),
+		Title:        shellQuote(pr.Title),
+		Author:       shellQuote(pr.Author),
+		Url:          shellQuote(pr.Url),
+		LocalPath:    shellQuote(localPath),
+	}
+	var script strings.Builder
+	if err := action.template.Execute(&script, ctx); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("sh", "-c", script.String())
+	cmd.Dir = localPath
+	return cmd, nil
+}
+
+func RunAction(action Action, pr prs.PullRequest, m rootModel) tea.Cmd {
+	cmd, err := actionCommand(action, pr, m)
+	if err != nil {
+		return NewErrorToast(action.Name + ": " + err.Error())
+	}
+
+	if action.Foreground {
+		return tea.ExecProcess(cmd, func(err error) tea.Msg {
+			if err != nil {
+				return model.MsgShowToast{Text: action.Name + " failed: " + err.Error(), Style: errorToastStyle}
+			}
+			return model.MsgShowToast{Text: action.Name + " finished", Style: successToastStyle}
+		})
+	}
+
+	return RunJob(m, pr.Uid(), action.Name, "", func(job jobContext) bool {
+		output, err := cmd.StdoutPipe()
+		if err != nil {
+			job.Toast(NewErrorToast(action.Name + " failed: " + err.Error()))
+			return false
+		}
+		cmd.Stderr = cmd.Stdout
+		job.Log("$ " + cmd.Args[len(cmd.Args)-1])
+		if err := cmd.Start(); err != nil {
+			job.Toast(NewErrorToast(action.Name + " failed: " + err.Error()))
+			return false
+		}
+
+		// The output goes to the jobs pane only, a toast per line would flood the status bar.
+		scanner := bufio.NewScanner(output)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				job.Log(line)
+			}
+		}
+
+		if err := cmd.Wait(); err != nil {
+			job.Toast(NewErrorToast(action.Name + " failed: " + err.Error()))
+			return false
+		}
+		job.Toast(NewToast(action.Name+" finished", true))
+		return true
+	})
+}
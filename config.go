@@ -1,10 +1,13 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/BurntSushi/toml"
+	"github.com/hejmsdz/bb/model"
 	"github.com/hejmsdz/bb/prs"
 	"github.com/kirsle/configdir"
 )
@@ -13,31 +16,95 @@ type Config struct {
 	UpdateIntervalMinutes int
 	Bitbucket             prs.AccountConfig
 	LocalRepositoryPaths  map[string]string
+	DiscoveryRoots        []string
+	SavedFilters          []model.SavedFilter
+	Views                 []model.View
+	Watch                 []model.WatchRule
+	ReviewerGroups        map[string][]string
+	Actions               []Action
+	CheckoutMode          string
+	WorktreesPath         string
+	ActivityRetentionDays int
 }
 
 var configDirPath string = configdir.LocalConfig("bb")
 var configFilePath string = filepath.Join(configDirPath, "/config.toml")
 var stateFilePath string = filepath.Join(configDirPath, "/state.json")
 
-func ReadConfig() (Config, bool) {
+const defaultActivityRetentionDays = 14
+
+var defaultWorktreesPath string = configdir.LocalCache("bb", "worktrees")
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+func ReadConfig() (Config, error) {
 	configdir.MakePath(configDirPath)
 
 	var config Config
 	tomlData, err := os.ReadFile(configFilePath)
 	if err != nil {
-		return Config{}, false
+		return Config{}, err
 	}
 	_, err = toml.Decode(string(tomlData), &config)
 	if err != nil {
-		return Config{}, false
+		return Config{}, fmt.Errorf("invalid configuration: %w", err)
+	}
+	switch config.CheckoutMode {
+	case "":
+		config.CheckoutMode = checkoutModeBranch
+	case checkoutModeBranch, checkoutModeWorktree:
+	default:
+		return Config{}, fmt.Errorf("invalid CheckoutMode %q", config.CheckoutMode)
+	}
+	if config.ActivityRetentionDays == 0 {
+		config.ActivityRetentionDays = defaultActivityRetentionDays
+	}
+	if config.WorktreesPath == "" {
+		config.WorktreesPath = defaultWorktreesPath
+	}
+	config.WorktreesPath = expandHome(config.WorktreesPath)
+	for repo, path := range config.LocalRepositoryPaths {
+		config.LocalRepositoryPaths[repo] = expandHome(path)
+	}
+	if err := config.Bitbucket.Validate(); err != nil {
+		return Config{}, err
 	}
-	return config, true
+	config.Bitbucket.RepositoryCachePath = filepath.Join(configdir.LocalCache("bb"), "repositories.json")
+	if err := config.Bitbucket.ResolveCredentials(); err != nil {
+		return Config{}, fmt.Errorf("could not resolve Bitbucket credentials: %w", err)
+	}
+	return config, nil
 }
 
 func CreateSampleConfig() error {
 	tomlData := ` # How often should the list of pull requests be updated?
 UpdateIntervalMinutes = 5
 
+# How to check out pull requests locally (see LocalRepositoryPaths below):
+# "branch" switches the branch in your local copy of the repository,
+# "worktree" creates a separate git worktree for every pull request.
+CheckoutMode = "branch"
+
+# Where to create worktrees in the "worktree" checkout mode.
+# WorktreesPath = "~/Code/worktrees"
+
+# For how many days to keep the activity feed (press A to see it).
+ActivityRetentionDays = 14
+
+# Directories to scan for clones of the repositories listed below.
+# Clones found there are used when LocalRepositoryPaths has no entry for a repository.
+# Run "bb discover --write" to save the discovered paths in this file.
+# DiscoveryRoots = ["~/Code"]
+
 [Bitbucket]
 # Your Bitbucket username.
 # If you don't remember it because you log in via an identity provider,
@@ -48,16 +115,78 @@ Username = ""
 # To generate an app password, go to: https://bitbucket.org/account/settings/app-passwords/new
 Password = ""
 
+# Instead of keeping the app password in this file, you can read it from
+# an environment variable, the output of a command or the system keyring.
+# The first one that is set wins.
+# PasswordEnv = "BITBUCKET_APP_PASSWORD"
+# PasswordCommand = "pass show bitbucket"
+# Looks up the secret with attributes service=<KeyringService> username=<Username>, e.g. stored with:
+# secret-tool store --label=bb service bb username <Username>
+# KeyringService = "bb"
+
 # Which repositories do you want to monitor?
+# Besides "owner/reponame", you can list all repositories of a workspace with "owner/*"
+# and of a project with "project:owner/KEY", or "project:KEY" to look in all your workspaces.
+# These are looked up in the Bitbucket API and refreshed every hour.
 Repositories = [
 	# "owner/reponame",
 ]
 
+# In the "reviewing" mode, bb looks at every repository of the listed workspaces,
+# in addition to the Repositories above, but asks the API only for the pull requests
+# you authored or review. Watch rules then can't match other pull requests.
+# Repositories without such pull requests are only looked at again once an hour.
+# Mode = "reviewing"
+# Workspaces = ["owner"]
+
 # Where are your local copies of these repositories?
 # Configuring these paths is optional, but it will allow you
 # to quickly checkout and update branches directly from the app.
 [LocalRepositoryPaths]
 # "owner/reponame" = "/home/you/Code/reponame"
+
+# Named filters that can be applied with a single key, one that bb doesn't use already.
+# Press the key again to clear the filter.
+# Filter syntax: repo:acme/api author:alice -approved:me updated:<2d has:changes has:conflicts
+# [[SavedFilters]]
+# Name = "Needs my review"
+# Key = "ctrl+r"
+# Query = "reviewer:me -approved:me"
+
+# Tabs switchable with number keys, the first one always shows all pull requests.
+# When no views are configured, a default set is used.
+# Sort is one of: updated, created, repository, author, approvals, attention.
+# [[Views]]
+# Name = "Needs my review"
+# Filter = "reviewer:me -approved:me"
+# Sort = "attention"
+
+# Custom commands run against the selected pull request, bound to keys that bb and the saved filters don't use.
+# The command is a template with the fields {{.Id}}, {{.Repo}}, {{.Branch}}, {{.TargetBranch}},
+# {{.Title}}, {{.Author}}, {{.Url}} and {{.LocalPath}}. The values are already shell-quoted.
+# The command runs in the local copy of the repository, if configured.
+# Background commands report their output in the status bar,
+# foreground commands take over the terminal until they exit.
+# [[Actions]]
+# Key = "T"
+# Name = "run tests"
+# Command = "make test BRANCH={{.Branch}}"
+# Foreground = false
+
+# Pull requests to follow even if you're neither the author nor a reviewer.
+# Every field that is set must match, Repo and Target may be glob patterns.
+# Press W to watch a single pull request and a to show all of them.
+# [[Watch]]
+# Repo = "acme/api"
+# [[Watch]]
+# Author = "Alice"
+# [[Watch]]
+# Target = "release/*"
+
+# Groups of reviewers added or removed at once in the reviewers picker (press R),
+# by display name or account id.
+# [ReviewerGroups]
+# backend = ["Alice", "Bob"]
 `
 	return os.WriteFile(configFilePath, []byte(tomlData), 0600)
 }
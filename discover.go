@@ -0,0 +1,187 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const discoveryMaxDepth = 4
+
+var remoteRepoRegexp = regexp.MustCompile(`[:/]([^/:]+/[^/]+?)(\.git)?/?$`)
+
+// parseRemoteRepo extracts "owner/repo" from a remote URL like
+// git@bitbucket.org:owner/repo.git or https://user@bitbucket.org/owner/repo.git.
+func parseRemoteRepo(url string) string {
+	match := remoteRepoRegexp.FindStringSubmatch(strings.TrimSpace(url))
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// DiscoverLocalRepos scans the root directories for clones of the monitored repositories.
+// A "workspace/*" entry matches clones of any repository in the workspace,
+// while project entries can't be matched without asking the API.
+func DiscoverLocalRepos(roots []string, repos []string) map[string]string {
+	wanted := make(map[string]string)
+	wantedWorkspaces := make(map[string]bool)
+	for _, repo := range repos {
+		if workspace := strings.TrimSuffix(repo, "/*"); workspace != repo {
+			wantedWorkspaces[strings.ToLower(workspace)] = true
+		} else {
+			wanted[strings.ToLower(repo)] = repo
+		}
+	}
+	findWanted := func(remoteRepo string) (string, bool) {
+		if repo, isWanted := wanted[strings.ToLower(remoteRepo)]; isWanted {
+			return repo, true
+		}
+		workspace := strings.SplitN(remoteRepo, "/", 2)[0]
+		return remoteRepo, remoteRepo != "" && wantedWorkspaces[strings.ToLower(workspace)]
+	}
+
+	found := make(map[string]string)
+	for _, root := range roots {
+		root = expandHome(root)
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+			rel, _ := filepath.Rel(root, path)
+			if strings.Count(rel, string(filepath.Separator)) >= discoveryMaxDepth {
+				return filepath.SkipDir
+			}
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, ".git")); err != nil {
+				return nil
+			}
+
+			url, ok := RunGitCommand(path, "remote", "get-url", "origin")
+			if repo, isWanted := findWanted(parseRemoteRepo(url)); ok && isWanted {
+				if _, exists := found[repo]; !exists {
+					found[repo] = path
+				}
+			}
+			return filepath.SkipDir
+		})
+	}
+	return found
+}
+
+type msgLocalReposDiscovered struct {
+	repos map[string]string
+}
+
+// discoverLocalReposCmd runs the discovery in the background, so that walking the roots
+// doesn't hold up the first frame.
+func discoverLocalReposCmd(roots []string, repos []string) tea.Cmd {
+	if len(roots) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		return msgLocalReposDiscovered{DiscoverLocalRepos(roots, repos)}
+	}
+}
+
+// mergeLocalRepos adds discovered paths for repositories that are not configured explicitly.
+func mergeLocalRepos(configured map[string]string, discovered map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for repo, path := range discovered {
+		merged[repo] = path
+	}
+	for repo, path := range configured {
+		merged[repo] = path
+	}
+	return merged
+}
+
+func formatLocalRepos(paths map[string]string) string {
+	repos := make([]string, 0, len(paths))
+	for repo := range paths {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	var lines strings.Builder
+	for _, repo := range repos {
+		fmt.Fprintf(&lines, "%q = %q\n", repo, paths[repo])
+	}
+	return lines.String()
+}
+
+// writeLocalRepos adds the paths to the [LocalRepositoryPaths] table of the config file,
+// keeping the rest of the file untouched.
+func writeLocalRepos(paths map[string]string) error {
+	data, err := os.ReadFile(configFilePath)
+	if err != nil {
+		return err
+	}
+	entries := formatLocalRepos(paths)
+
+	lines := strings.SplitAfter(string(data), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "[LocalRepositoryPaths]" {
+			updated := strings.Join(lines[:i+1], "") + entries + strings.Join(lines[i+1:], "")
+			return os.WriteFile(configFilePath, []byte(updated), 0600)
+		}
+	}
+
+	updated := string(data)
+	if !strings.HasSuffix(updated, "\n") {
+		updated += "\n"
+	}
+	updated += "\n[LocalRepositoryPaths]\n" + entries
+	return os.WriteFile(configFilePath, []byte(updated), 0600)
+}
+
+func runDiscover(config Config, args []string) {
+	flags := flag.NewFlagSet("discover", flag.ExitOnError)
+	write := flags.Bool("write", false, "add the discovered paths to "+configFilePath)
+	flags.Parse(args)
+
+	roots := flags.Args()
+	if len(roots) == 0 {
+		roots = config.DiscoveryRoots
+	}
+	if len(roots) == 0 {
+		fmt.Println(errorToastStyle.Render("No directories to scan."))
+		fmt.Println("Pass them as arguments: bb discover ~/Code, or set DiscoveryRoots in the file:")
+		fmt.Println(infoToastStyle.Render(configFilePath))
+		os.Exit(1)
+	}
+
+	discovered := DiscoverLocalRepos(roots, config.Bitbucket.RepositoryEntries())
+	newPaths := make(map[string]string)
+	for repo, path := range discovered {
+		if _, configured := config.LocalRepositoryPaths[repo]; !configured {
+			newPaths[repo] = path
+		}
+	}
+
+	if len(newPaths) == 0 {
+		fmt.Println("No new local repositories found.")
+		return
+	}
+	fmt.Print(formatLocalRepos(newPaths))
+	if !*write {
+		fmt.Println()
+		fmt.Println("Run " + infoToastStyle.Render("bb discover --write") + " to add these paths to your configuration.")
+		return
+	}
+	if err := writeLocalRepos(newPaths); err != nil {
+		fmt.Println(errorToastStyle.Render("Could not update the configuration: " + err.Error()))
+		os.Exit(1)
+	}
+	fmt.Println(successToastStyle.Render(fmt.Sprintf("Added %d paths to %s", len(newPaths), configFilePath)))
+}
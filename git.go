@@ -1,11 +1,13 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hejmsdz/bb/model"
 	"github.com/hejmsdz/bb/prs"
 )
 
@@ -21,6 +23,9 @@ func CheckLocalDir(pr prs.PullRequest, m rootModel) (string, tea.Cmd) {
 	return localDir, nil
 }
 
+// GitRunner runs a git command in a directory, RunGitCommand is the default one.
+type GitRunner func(localDir string, args ...string) (string, bool)
+
 func RunGitCommand(localDir string, args ...string) (string, bool) {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = localDir
@@ -29,50 +34,309 @@ func RunGitCommand(localDir string, args ...string) (string, bool) {
 	return out, err == nil
 }
 
+func localBranchExists(git GitRunner, localDir string, branch string) bool {
+	_, ok := git(localDir, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	return ok
+}
+
+// forkCloneUrl derives the clone URL of a fork from the URL of origin,
+// so that it uses the same protocol and host.
+func forkCloneUrl(originUrl string, repo string, forkRepo string) string {
+	if i := strings.Index(strings.ToLower(originUrl), strings.ToLower(repo)); i >= 0 {
+		return originUrl[:i] + forkRepo + originUrl[i+len(repo):]
+	}
+	return "https://bitbucket.org/" + forkRepo + ".git"
+}
+
+// FetchPrBranch fetches the source branch of the pull request and returns the remote it was fetched from.
+// Branches from forks are fetched from a remote named after the fork's owner, which is added when missing.
+func FetchPrBranch(git GitRunner, localDir string, pr prs.PullRequest) (string, string, bool) {
+	remote := "origin"
+	if pr.SourceRepo != "" && !strings.EqualFold(pr.SourceRepo, pr.Repo) {
+		remote = strings.Split(pr.SourceRepo, "/")[0]
+		if _, exists := git(localDir, "remote", "get-url", remote); !exists {
+			originUrl, _ := git(localDir, "remote", "get-url", "origin")
+			url := forkCloneUrl(originUrl, pr.Repo, pr.SourceRepo)
+			if out, ok := git(localDir, "remote", "add", remote, url); !ok {
+				return remote, out, false
+			}
+		}
+	}
+	out, ok := git(localDir, "fetch", remote, pr.Branch)
+	return remote, out, ok
+}
+
+// CompareWithPr tells how the local branch relates to the last commit of the pull request.
+func CompareWithPr(git GitRunner, localDir string, pr prs.PullRequest) string {
+	out, ok := git(localDir, "rev-list", "--left-right", "--count", pr.Branch+"..."+pr.LastCommit)
+	if !ok {
+		return "unknown relation to the pull request"
+	}
+	var ahead, behind int
+	fmt.Sscan(out, &ahead, &behind)
+	switch {
+	case ahead == 0 && behind == 0:
+		return "up to date with the pull request"
+	case behind == 0:
+		return fmt.Sprintf("%d ahead of the pull request", ahead)
+	case ahead == 0:
+		return fmt.Sprintf("%d behind the pull request", behind)
+	}
+	return fmt.Sprintf("%d ahead, %d behind the pull request", ahead, behind)
+}
+
+// CheckoutPrBranch switches to the local branch of the pull request,
+// creating it to track the fetched remote branch if needed.
+func CheckoutPrBranch(git GitRunner, localDir string, pr prs.PullRequest) (string, bool) {
+	remote, out, ok := FetchPrBranch(git, localDir, pr)
+	if !ok {
+		return out, false
+	}
+	if localBranchExists(git, localDir, pr.Branch) {
+		return git(localDir, "checkout", pr.Branch)
+	}
+	return git(localDir, "checkout", "--track", "-b", pr.Branch, remote+"/"+pr.Branch)
+}
+
 func Checkout(pr prs.PullRequest, m rootModel) tea.Cmd {
-	return func() tea.Msg {
-		ch := m.async.GetChannel()
-		localDir, teaCmd := CheckLocalDir(pr, m)
-		if localDir == "" {
-			ch <- teaCmd
-			return nil
+	if m.checkoutMode == checkoutModeWorktree {
+		return CheckoutWorktree(pr, m)
+	}
+	localDir, teaCmd := CheckLocalDir(pr, m)
+	if localDir == "" {
+		return teaCmd
+	}
+	return RunJob(m, pr.Uid(), "checkout "+pr.Branch, localDir, func(job jobContext) bool {
+		out, ok := CheckoutPrBranch(job.Git, localDir, pr)
+		if ok {
+			out = fmt.Sprintf("Switched to %s (%s)", pr.Branch, CompareWithPr(job.Git, localDir, pr))
 		}
-		out, ok := RunGitCommand(localDir, "checkout", pr.Branch)
-		ch <- NewToast(out, ok)
+		job.Toast(NewToast(out, ok))
+		job.Toast(LoadLocalStatuses(m))
+		return ok
+	})
+}
+
+// worktreeBranches returns the branches checked out in the repository and all its worktrees.
+func worktreeBranches(git GitRunner, localDir string) map[string]bool {
+	branches := make(map[string]bool)
+	out, _ := git(localDir, "worktree", "list", "--porcelain")
+	for _, line := range strings.Split(out, "\n") {
+		if branch := strings.TrimPrefix(line, "branch refs/heads/"); branch != line {
+			branches[branch] = true
+		}
+	}
+	return branches
+}
+
+func localBranchStatus(git GitRunner, localDir string, pr prs.PullRequest, checkedOut map[string]bool) model.LocalBranchStatus {
+	status := model.LocalBranchStatus{}
+	if !localBranchExists(git, localDir, pr.Branch) {
+		return status
+	}
+	status.Exists = true
+	status.CheckedOut = checkedOut[pr.Branch]
+
+	if _, fetched := git(localDir, "cat-file", "-e", pr.LastCommit+"^{commit}"); fetched {
+		out, _ := git(localDir, "rev-list", "--left-right", "--count", pr.Branch+"..."+pr.LastCommit)
+		fmt.Sscan(out, &status.Unpushed, &status.Behind)
+		return status
+	}
+
+	status.Behind = -1
+	out, _ := git(localDir, "rev-list", "--count", pr.Branch+"@{upstream}.."+pr.Branch)
+	fmt.Sscan(out, &status.Unpushed)
+	return status
+}
+
+// LoadLocalStatuses compares the pull requests with their branches in the configured local repositories.
+// Each repository is read under its job lock, so that it is never caught in the middle of a job.
+func LoadLocalStatuses(m rootModel) tea.Cmd {
+	if len(m.localRepos) == 0 {
 		return nil
 	}
+	prList, localRepos, runner := m.Prs.Prs, m.localRepos, m.jobRunner
+	return func() tea.Msg {
+		prsByDir := make(map[string][]prs.PullRequest)
+		for _, pr := range prList {
+			if localDir, exists := localRepos[pr.Repo]; exists {
+				prsByDir[localDir] = append(prsByDir[localDir], pr)
+			}
+		}
+
+		statuses := make(map[prs.Uid]model.LocalBranchStatus)
+		for localDir, dirPrs := range prsByDir {
+			lock := runner.lock(localDir)
+			lock.Lock()
+			checkedOut := worktreeBranches(RunGitCommand, localDir)
+			for _, pr := range dirPrs {
+				statuses[pr.Uid()] = localBranchStatus(RunGitCommand, localDir, pr, checkedOut)
+			}
+			lock.Unlock()
+		}
+		return model.MsgLocalStatusLoaded{Statuses: statuses}
+	}
 }
 
+const (
+	updateByMerge  = "merge"
+	updateByRebase = "rebase"
+)
+
+// PullOrigin updates the pull request branch with its target branch.
+// It asks whether to merge or rebase, and before pushing the result.
 func PullOrigin(pr prs.PullRequest, m rootModel) tea.Cmd {
 	return func() tea.Msg {
-		localDir, teaCmd := CheckLocalDir(pr, m)
-		ch := m.async.GetChannel()
-		if localDir == "" {
-			ch <- teaCmd
-			return nil
+		return model.MsgPrompt{
+			Text: fmt.Sprintf("Update %s from %s?", pr.Branch, pr.TargetBranch),
+			Choices: []model.Choice{
+				{Key: "m", Label: "merge", Cmd: updateBranch(pr, m, updateByMerge)},
+				{Key: "r", Label: "rebase", Cmd: updateBranch(pr, m, updateByRebase)},
+			},
 		}
-		changes, _ := RunGitCommand(localDir, "status", "--short")
-		if changes != "" {
-			RunGitCommand(localDir, "stash")
-			defer RunGitCommand(localDir, "stash", "pop")
+	}
+}
+
+type branchUpdate struct {
+	m              rootModel
+	job            jobContext
+	pr             prs.PullRequest
+	localDir       string
+	strategy       string
+	remote         string
+	originalBranch string
+	stash          string
+}
+
+func (u branchUpdate) git(args ...string) (string, bool) {
+	return u.job.Git(u.localDir, args...)
+}
+
+// restore brings back the branch and the uncommitted changes the user had before the update.
+func (u branchUpdate) restore() (string, bool) {
+	if out, ok := u.git("checkout", u.originalBranch); !ok {
+		return "Could not switch back to " + u.originalBranch + ": " + out, false
+	}
+	if u.stash == "" {
+		return "", true
+	}
+	stashes, _ := u.git("stash", "list", "--format=%H")
+	for i, hash := range strings.Split(stashes, "\n") {
+		if hash != u.stash {
+			continue
 		}
+		ref := fmt.Sprintf("stash@{%d}", i)
+		if out, ok := u.git("stash", "pop", ref); !ok {
+			return "Could not restore uncommitted changes, they are kept in " + ref + ": " + out, false
+		}
+		return "", true
+	}
+	return "Could not find the stash with uncommitted changes " + u.stash, false
+}
+
+// abort restores the original state and reports why the update failed.
+func (u branchUpdate) abort(reason string) bool {
+	u.job.Toast(NewErrorToast(reason))
+	if out, ok := u.restore(); !ok {
+		u.job.Toast(NewErrorToast(out))
+	}
+	return false
+}
 
-		gitCommands := [][]string{
-			{"checkout", pr.Branch},
-			{"pull", "origin", pr.TargetBranch, "--no-edit"},
-			{"push", "--no-verify"},
-			{"checkout", "-"},
+func updateBranch(pr prs.PullRequest, m rootModel, strategy string) tea.Cmd {
+	localDir, teaCmd := CheckLocalDir(pr, m)
+	if localDir == "" {
+		return teaCmd
+	}
+	return RunJob(m, pr.Uid(), strategy+" "+pr.TargetBranch+" into "+pr.Branch, localDir, func(job jobContext) bool {
+		ok := branchUpdate{m: m, job: job, pr: pr, localDir: localDir, strategy: strategy}.run()
+		job.Toast(LoadLocalStatuses(m))
+		return ok
+	})
+}
+
+func (u branchUpdate) run() bool {
+	branch, ok := u.git("symbolic-ref", "--quiet", "--short", "HEAD")
+	if !ok {
+		branch, ok = u.git("rev-parse", "HEAD")
+	}
+	if !ok {
+		u.job.Toast(NewErrorToast("Could not determine the current branch: " + branch))
+		return false
+	}
+	u.originalBranch = branch
+
+	changes, _ := u.git("status", "--porcelain")
+	if changes != "" {
+		if out, ok := u.git("stash", "push", "--include-untracked", "-m", "bb: update "+u.pr.Branch); !ok {
+			u.job.Toast(NewErrorToast("Could not stash uncommitted changes: " + out))
+			return false
 		}
+		u.stash, _ = u.git("rev-parse", "stash@{0}")
+	}
 
-		for _, args := range gitCommands {
-			out, ok := RunGitCommand(localDir, args...)
-			ch <- NewToast(out, ok)
-			if !ok {
-				return nil
-			}
+	remote, out, ok := FetchPrBranch(u.job.Git, u.localDir, u.pr)
+	if !ok {
+		return u.abort("Could not fetch " + u.pr.Branch + ": " + out)
+	}
+	u.remote = remote
+	if out, ok := CheckoutPrBranch(u.job.Git, u.localDir, u.pr); !ok {
+		return u.abort("Could not check out " + u.pr.Branch + ": " + out)
+	}
+	if out, ok := u.git("merge", "--ff-only", remote+"/"+u.pr.Branch); !ok {
+		return u.abort("Local " + u.pr.Branch + " has diverged from " + remote + ": " + out)
+	}
+	if out, ok := u.git("fetch", "origin", u.pr.TargetBranch); !ok {
+		return u.abort("Could not fetch " + u.pr.TargetBranch + ": " + out)
+	}
+
+	before, _ := u.git("rev-parse", "HEAD")
+	target := "origin/" + u.pr.TargetBranch
+	if u.strategy == updateByRebase {
+		if _, ok := u.git("rebase", target); !ok {
+			u.git("rebase", "--abort")
+			return u.abort("Rebasing " + u.pr.Branch + " onto " + target + " failed with conflicts, aborted")
+		}
+	} else {
+		if _, ok := u.git("merge", "--no-edit", target); !ok {
+			u.git("merge", "--abort")
+			return u.abort("Merging " + target + " into " + u.pr.Branch + " failed with conflicts, aborted")
 		}
+	}
+	after, _ := u.git("rev-parse", "HEAD")
+	if before == after {
+		if out, ok := u.restore(); !ok {
+			u.job.Toast(NewErrorToast(out))
+			return false
+		}
+		u.job.Toast(NewInfoToast(u.pr.Branch + " is already up to date with " + u.pr.TargetBranch))
+		return true
+	}
 
-		ch <- NewToast("Pulled & pushed", true)
-		return nil
+	if !u.job.Confirm(fmt.Sprintf("Push the %s %s to %s?", u.strategy, u.pr.Branch, u.remote)) {
+		if out, ok := u.restore(); !ok {
+			u.job.Toast(NewErrorToast(out))
+			return false
+		}
+		u.job.Toast(NewInfoToast("Not pushed, the updated " + u.pr.Branch + " is kept locally"))
+		return true
+	}
+	return u.push()
+}
+
+func (u branchUpdate) push() bool {
+	args := []string{"push", u.remote, "refs/heads/" + u.pr.Branch + ":" + u.pr.Branch}
+	if u.strategy == updateByRebase {
+		args = append(args, "--force-with-lease="+u.pr.Branch+":"+u.pr.LastCommit)
+	}
+	out, ok := u.git(args...)
+	if !ok {
+		return u.abort("Push failed: " + out)
+	}
+	u.job.Toast(NewToast("Pulled & pushed", true))
+	if out, ok := u.restore(); !ok {
+		u.job.Toast(NewErrorToast(out))
+		return false
 	}
+	return true
 }
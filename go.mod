@@ -3,13 +3,17 @@ module github.com/hejmsdz/bb
 go 1.17
 
 require (
-	github.com/BurntSushi/toml v1.2.0 // indirect
-	github.com/atotto/clipboard v0.1.4 // indirect
-	github.com/charmbracelet/bubbles v0.13.0 // indirect
-	github.com/charmbracelet/bubbletea v0.22.0 // indirect
-	github.com/charmbracelet/lipgloss v0.5.0 // indirect
+	github.com/BurntSushi/toml v1.2.0
+	github.com/atotto/clipboard v0.1.4
+	github.com/charmbracelet/bubbles v0.13.0
+	github.com/charmbracelet/bubbletea v0.22.0
+	github.com/charmbracelet/lipgloss v0.5.0
+	github.com/kirsle/configdir v0.0.0-20170128060238-e45d2f54772f
+	github.com/pkg/browser v0.0.0-20210911075715-681adbf594b8
+)
+
+require (
 	github.com/containerd/console v1.0.3 // indirect
-	github.com/kirsle/configdir v0.0.0-20170128060238-e45d2f54772f // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-isatty v0.0.14 // indirect
 	github.com/mattn/go-runewidth v0.0.13 // indirect
@@ -17,7 +21,6 @@ require (
 	github.com/muesli/cancelreader v0.2.1 // indirect
 	github.com/muesli/reflow v0.3.0 // indirect
 	github.com/muesli/termenv v0.11.1-0.20220212125758-44cd13922739 // indirect
-	github.com/pkg/browser v0.0.0-20210911075715-681adbf594b8 // indirect
 	github.com/rivo/uniseg v0.2.0 // indirect
 	github.com/sahilm/fuzzy v0.1.0 // indirect
 	golang.org/x/sys v0.0.0-20220209214540-3681064d5158 // indirect
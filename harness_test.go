@@ -0,0 +1,147 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hejmsdz/bb/model"
+	"github.com/hejmsdz/bb/prs"
+)
+
+// harness drives rootModel like the Bubble Tea runtime does, but synchronously from a test.
+// Commands run in goroutines and their messages are fed back to Update until the model settles.
+type harness struct {
+	t      *testing.T
+	model  rootModel
+	msgs   chan tea.Msg
+	toasts []string
+}
+
+func testConfig() Config {
+	return Config{
+		UpdateIntervalMinutes: 60,
+		CheckoutMode:          checkoutModeBranch,
+	}
+}
+
+func newHarness(t *testing.T, client prs.Client, config Config) *harness {
+	t.Helper()
+	stateFilePath = filepath.Join(t.TempDir(), "state.json")
+	return startHarness(t, client, config)
+}
+
+// restart saves the state like quitting bb does, and starts it again with a new client.
+func (h *harness) restart(client prs.Client, config Config) *harness {
+	if err := h.model.dump(); err != nil {
+		h.t.Fatalf("dump: %v", err)
+	}
+	return startHarness(h.t, client, config)
+}
+
+func startHarness(t *testing.T, client prs.Client, config Config) *harness {
+	t.Helper()
+	m, err := newRootModel(config, client)
+	if err != nil {
+		t.Fatalf("newRootModel: %v", err)
+	}
+	if err := m.load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	h := &harness{t: t, model: m, msgs: make(chan tea.Msg, 100)}
+	h.run(m.Init())
+	h.send(tea.WindowSizeMsg{Width: 160, Height: 50})
+	h.settle()
+	return h
+}
+
+func (h *harness) run(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	go func() {
+		h.msgs <- cmd()
+	}()
+}
+
+var cmdType = reflect.TypeOf(tea.Cmd(nil))
+
+func (h *harness) send(msg tea.Msg) {
+	if msg == nil {
+		return
+	}
+	// tea.Batch produces an unexported slice of commands.
+	if v := reflect.ValueOf(msg); v.Kind() == reflect.Slice && v.Type().Elem() == cmdType {
+		for i := 0; i < v.Len(); i++ {
+			h.run(v.Index(i).Interface().(tea.Cmd))
+		}
+		return
+	}
+	if toast, ok := msg.(model.MsgShowToast); ok {
+		h.toasts = append(h.toasts, toast.Text)
+	}
+	next, cmd := h.model.Update(msg)
+	h.model = next.(rootModel)
+	h.run(cmd)
+}
+
+// settle processes messages until none arrives for a while.
+// Commands that block, like waiting for the next auto-update, are left behind.
+func (h *harness) settle() {
+	deadline := time.After(3 * time.Second)
+	for {
+		select {
+		case msg := <-h.msgs:
+			h.send(msg)
+		case <-time.After(50 * time.Millisecond):
+			return
+		case <-deadline:
+			h.t.Fatal("model did not settle")
+		}
+	}
+}
+
+func keyMsg(key string) tea.KeyMsg {
+	switch key {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "up":
+		return tea.KeyMsg{Type: tea.KeyUp}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "backspace":
+		return tea.KeyMsg{Type: tea.KeyBackspace}
+	case "ctrl+s":
+		return tea.KeyMsg{Type: tea.KeyCtrlS}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+// press sends each key and waits for the model to settle after it.
+func (h *harness) press(keys ...string) {
+	for _, key := range keys {
+		h.send(keyMsg(key))
+		h.settle()
+	}
+}
+
+func (h *harness) typeText(text string) {
+	for _, r := range text {
+		h.send(keyMsg(string(r)))
+	}
+	h.settle()
+}
+
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// view returns the rendered model without styling, so it can be searched for text.
+func (h *harness) view() string {
+	return ansiEscape.ReplaceAllString(h.model.View(), "")
+}
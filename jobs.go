@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hejmsdz/bb/model"
+)
+
+type JobRunner struct {
+	mu     sync.Mutex
+	locks  map[string]*sync.Mutex
+	nextId int
+}
+
+func NewJobRunner() *JobRunner {
+	return &JobRunner{
+		locks: make(map[string]*sync.Mutex),
+	}
+}
+
+func (r *JobRunner) newId() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextId++
+	return r.nextId
+}
+
+func (r *JobRunner) lock(dir string) *sync.Mutex {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.locks[dir]; !exists {
+		r.locks[dir] = &sync.Mutex{}
+	}
+	return r.locks[dir]
+}
+
+// jobContext is passed to a running job to report its progress.
+type jobContext struct {
+	id int
+	ch chan tea.Cmd
+}
+
+func (j jobContext) send(msg tea.Msg) {
+	j.ch <- func() tea.Msg { return msg }
+}
+
+func (j jobContext) Log(line string) {
+	j.send(model.MsgJobOutput{Id: j.id, Line: line})
+}
+
+func (j jobContext) Toast(cmd tea.Cmd) {
+	j.ch <- cmd
+}
+
+// Confirm asks the user a yes or no question and waits for the answer.
+// The job keeps its lock meanwhile, so that no other job changes the clone before it goes on.
+func (j jobContext) Confirm(text string) bool {
+	j.Log(text)
+	answer := make(chan bool, 1)
+	reply := func(yes bool) tea.Cmd {
+		return func() tea.Msg {
+			answer <- yes
+			return nil
+		}
+	}
+	j.Toast(model.Confirm(text, reply(true), reply(false)))
+	return <-answer
+}
+
+// Git runs a git command, recording it and its output in the job.
+func (j jobContext) Git(localDir string, args ...string) (string, bool) {
+	j.Log("$ git " + strings.Join(args, " "))
+	out, ok := RunGitCommand(localDir, args...)
+	if out != "" {
+		for _, line := range strings.Split(out, "\n") {
+			j.Log(line)
+		}
+	}
+	return out, ok
+}
+
+// RunJob runs fn in the background and tracks it in the jobs pane.
+// The subject tells what the job works on, usually a pull request uid.
+// Jobs with the same lockDir run one at a time, so that git commands never overlap in the same clone.
+// An empty lockDir means the job does not need to wait for others.
+func RunJob(m rootModel, subject string, name string, lockDir string, fn func(job jobContext) bool) tea.Cmd {
+	return func() tea.Msg {
+		job := jobContext{m.jobRunner.newId(), m.async.GetChannel()}
+		job.send(model.MsgJobQueued{Job: model.Job{Id: job.id, Subject: subject, Name: name, Status: model.JobQueued}})
+
+		if lockDir != "" {
+			lock := m.jobRunner.lock(lockDir)
+			lock.Lock()
+			defer lock.Unlock()
+		}
+
+		job.send(model.MsgJobStarted{Id: job.id, StartedAt: time.Now()})
+		ok := fn(job)
+		job.send(model.MsgJobFinished{Id: job.id, Ok: ok, FinishedAt: time.Now()})
+		return nil
+	}
+}
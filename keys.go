@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hejmsdz/bb/model"
+)
+
+// reservedKeys are handled by bb itself, including the navigation keys of the list.
+// Saved filters and actions can't use them, the built-in ones would be shadowed or shadow them.
+var reservedKeys = map[string]bool{
+	"ctrl+c": true, "r": true, ".": true, "m": true, "a": true, "s": true, "D": true,
+	"f": true, "F": true, "X": true, "J": true, "A": true, "o": true, "O": true,
+	"1": true, "2": true, "3": true, "4": true, "5": true, "6": true, "7": true, "8": true, "9": true,
+	"i": true, "d": true, "w": true, "W": true, "R": true, "E": true, "u": true, "b": true,
+	"c": true, "P": true, "e": true, "t": true, "enter": true,
+	"up": true, "k": true, "down": true, "j": true, "left": true, "h": true, "pgup": true,
+	"right": true, "l": true, "pgdown": true, "home": true, "g": true, "end": true, "G": true,
+	"/": true, "esc": true, "?": true, "q": true,
+}
+
+// ValidateSavedFilters makes sure every saved filter has a free key of its own and a query that parses.
+func ValidateSavedFilters(filters []model.SavedFilter) error {
+	names := make(map[string]string)
+	for _, filter := range filters {
+		switch {
+		case filter.Key == "":
+			return fmt.Errorf("saved filter %q has no key", filter.Name)
+		case reservedKeys[filter.Key]:
+			return fmt.Errorf("saved filter %q: the key %q is taken by bb", filter.Name, filter.Key)
+		case names[filter.Key] != "":
+			return fmt.Errorf("saved filter %q: the key %q is taken by %q", filter.Name, filter.Key, names[filter.Key])
+		}
+		if _, err := model.ParseQuery(filter.Query); err != nil {
+			return fmt.Errorf("saved filter %q: %w", filter.Name, err)
+		}
+		names[filter.Key] = filter.Name
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/hejmsdz/bb/model"
 	"github.com/hejmsdz/bb/prs"
 )
 
@@ -12,32 +13,85 @@ type PullRequestItem struct {
 	Pr          prs.PullRequest
 	WhatChanged []string
 	IsIgnored   bool
+	LocalStatus model.LocalBranchStatus
+	Involvement model.Involvement
+	// StaleApprovals counts the approvals given before the last commit.
+	StaleApprovals    int
+	IsMyApprovalStale bool
 }
 
 func (i PullRequestItem) Title() string {
 	if i.IsIgnored {
 		return ignoredStyle.Render(i.Pr.Title)
 	}
+	title := i.Pr.Title
+	if i.Pr.IsDraft {
+		title = draftStyle.Render("[draft] ") + title
+	}
+	switch i.Involvement {
+	case model.Watching:
+		title = "👁 " + title
+	case model.NotInvolved:
+		title = notInvolvedStyle.Render(title)
+	}
 	if len(i.WhatChanged) > 0 {
-		return fmt.Sprint("🔔 ", i.Pr.Title, " [", updatesStyle.Render(strings.Join(i.WhatChanged, ", ")), "]")
+		return fmt.Sprint("🔔 ", title, " [", updatesStyle.Render(strings.Join(i.WhatChanged, ", ")), "]")
 	}
-	return i.Pr.Title
+	return title
 }
 func (i PullRequestItem) FilterValue() string { return fmt.Sprint(i.Pr.Title, i.Pr.Author) }
 func (i PullRequestItem) Description() string {
 	timeAgo := TimeAgo(i.Pr.UpdatedOn)
 	var myReviewEmoji = ""
-	if i.Pr.MyReview == prs.Approved {
+	if i.Pr.MyReview == prs.Approved && i.IsMyApprovalStale {
+		myReviewEmoji = " / ✅" + staleApprovalStyle.Render(" stale")
+	} else if i.Pr.MyReview == prs.Approved {
 		myReviewEmoji = " / ✅"
 	} else if i.Pr.MyReview == prs.RequestedChanges {
 		myReviewEmoji = " / 👎"
 	}
+	approvals := approvesStyle.Render(fmt.Sprint(i.Pr.ApprovedCount))
+	if i.StaleApprovals > 0 {
+		approvals += staleApprovalStyle.Render(fmt.Sprintf(" (%d stale)", i.StaleApprovals))
+	}
 	reviewSummary := fmt.Sprintf("%s / %s%s",
-		approvesStyle.Render(fmt.Sprint(i.Pr.ApprovedCount)),
+		approvals,
 		requestedChangesStyle.Render(fmt.Sprint(i.Pr.RequestedChangesCount)),
 		myReviewEmoji,
 	)
-	return fmt.Sprintf("%s | %s | %d 💬 | %s", i.Pr.Author, timeAgo, i.Pr.CommentsCount, reviewSummary)
+	state := model.Classify(i.Pr, i.WhatChanged)
+	description := fmt.Sprintf("%s %s | %s | %s | %d 💬 | %s", state.Badge(), state, i.Pr.Author, timeAgo, i.Pr.CommentsCount, reviewSummary)
+	if localStatus := i.LocalStatus.String(); localStatus != "" {
+		description += " | " + localStatusStyle.Render(localStatus)
+	}
+	return description
+}
+
+// ClosedPullRequestItem is a pull request merged or declined since the previous refresh.
+type ClosedPullRequestItem struct {
+	Pr prs.PullRequest
+}
+
+func (i ClosedPullRequestItem) Title() string {
+	return closedStyle.Render(fmt.Sprint(i.Pr.Title, " [", model.ClosedChange(i.Pr), "]"))
+}
+func (i ClosedPullRequestItem) FilterValue() string { return fmt.Sprint(i.Pr.Title, i.Pr.Author) }
+func (i ClosedPullRequestItem) Description() string {
+	return fmt.Sprintf("%s | %s | %s", strings.ToLower(string(i.Pr.State)), i.Pr.Repo, i.Pr.Author)
+}
+
+type SectionHeaderItem struct {
+	Name  string
+	Count int
+}
+
+func (i *SectionHeaderItem) Title() string       { return sectionHeaderStyle.Render(i.Name) }
+func (i *SectionHeaderItem) FilterValue() string { return "" }
+func (i *SectionHeaderItem) Description() string {
+	if i.Count == 1 {
+		return "1 pull request"
+	}
+	return fmt.Sprintf("%d pull requests", i.Count)
 }
 
 func TimeAgo(t time.Time) string {
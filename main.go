@@ -1,8 +1,10 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"net/http"
 	"os"
 	"time"
 
@@ -28,9 +30,16 @@ var (
 	ignoredStyle          = lipgloss.NewStyle().Faint(true).Strikethrough(true)
 	approvesStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
 	requestedChangesStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	localStatusStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("4"))
+	closedStyle           = lipgloss.NewStyle().Faint(true)
+	notInvolvedStyle      = lipgloss.NewStyle().Faint(true)
+	draftStyle            = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	staleApprovalStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	sectionHeaderStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("5")).Bold(true)
 	updatesStyle          = lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Bold(true)
 	infoToastStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
 	successToastStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	warningToastStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
 	errorToastStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
 )
 
@@ -38,37 +47,52 @@ type rootModel struct {
 	Ignores      model.IgnoresModel
 	WhatChanged  model.WhatChangedModel
 	QuickFilters model.QuickFiltersModel
-	prs          model.PrsModel
+	Sorting      model.SortingModel
+	Query        model.QueryModel
+	Views        model.ViewsModel
+	Prs          model.PrsModel
+	Activity     model.ActivityModel
+	Watch        model.WatchModel
+	Approvals    model.ApprovalsModel
+	Reviewers    model.ReviewersModel
 	list         list.Model
 	autoUpdate   model.AutoUpdateModel
 	async        model.AsyncModel
+	prompt       model.PromptModel
+	localStatus  model.LocalStatusModel
+	jobs         model.JobsModel
+	changesPopup model.ChangesPopupModel
+	editForm     model.EditFormModel
+	conflicts    model.ConflictsModel
+	jobRunner    *JobRunner
+	client       prs.Client
 	localRepos   map[string]string
+	discovery    tea.Cmd
+	checkoutMode string
+	worktreesDir string
+	savedFilters []model.SavedFilter
+	actions      []Action
+	stateNotice  string
+	width        int
+	height       int
 	quitting     bool
 }
 
 func (m rootModel) Init() tea.Cmd {
+	var noticeCmd tea.Cmd
+	if m.stateNotice != "" {
+		noticeCmd = NewErrorToast(m.stateNotice)
+	}
 	return tea.Batch(
-		m.prs.Init(),
+		model.UpdateListView,
+		m.Prs.Init(),
 		m.autoUpdate.Init(),
 		m.async.Init(),
+		m.discovery,
+		noticeCmd,
 	)
 }
 
-func (m rootModel) dump() tea.Msg {
-	file, _ := json.MarshalIndent(m, "", " ")
-	os.WriteFile(stateFilePath, file, 0600)
-	return nil
-}
-
-func (m *rootModel) load() bool {
-	data, err := os.ReadFile(stateFilePath)
-	if err != nil {
-		return false
-	}
-	err = json.Unmarshal(data, &m)
-	return err != nil
-}
-
 func NewInfoToast(text string) tea.Cmd {
 	return func() tea.Msg {
 		return model.MsgShowToast{Text: text, Style: infoToastStyle}
@@ -85,12 +109,32 @@ func NewToast(text string, isOk bool) tea.Cmd {
 	}
 }
 
+func NewWarningToast(text string) tea.Cmd {
+	return func() tea.Msg {
+		return model.MsgShowToast{Text: text, Style: warningToastStyle}
+	}
+}
+
 func NewErrorToast(text string) tea.Cmd {
 	return func() tea.Msg {
 		return model.MsgShowToast{Text: text, Style: errorToastStyle}
 	}
 }
 
+func ToggleWatch(pr prs.PullRequest, m rootModel) tea.Cmd {
+	if m.Watch.Involvement(pr) == model.Involved {
+		return NewInfoToast("You're already involved in this pull request")
+	}
+	if m.Watch.IsWatchedByRule(pr) {
+		return NewInfoToast("This pull request is watched by a rule in the configuration")
+	}
+	isWatched, cmd := m.Watch.ToggleWatch(pr)
+	if isWatched {
+		return tea.Batch(cmd, NewInfoToast("Watching "+pr.Title))
+	}
+	return tea.Batch(cmd, NewInfoToast("Stopped watching "+pr.Title))
+}
+
 func OpenBrowser(url string) tea.Cmd {
 	return func() tea.Msg {
 		browser.OpenURL(url)
@@ -104,19 +148,59 @@ func CopyToClipboard(str string, m rootModel) tea.Cmd {
 }
 
 func UpdateListView(m *rootModel) {
+	filteredPrs := make([]prs.PullRequest, 0)
+	for _, pr := range m.Prs.Prs {
+		pr = m.conflicts.Annotate(pr)
+		if m.Watch.IsHidden(pr) || m.Ignores.IsHidden(pr) || m.QuickFilters.IsHidden(pr) || m.Approvals.IsHidden(pr) || m.Query.IsHidden(pr, m.WhatChanged.WhatChanged(pr)) {
+			continue
+		}
+		filteredPrs = append(filteredPrs, pr)
+	}
+	m.Views.Count(filteredPrs, m.WhatChanged.WhatChanged)
+
+	visiblePrs := make([]prs.PullRequest, 0)
+	for _, pr := range filteredPrs {
+		if !m.Views.IsHidden(pr, m.WhatChanged.WhatChanged(pr)) {
+			visiblePrs = append(visiblePrs, pr)
+		}
+	}
+
+	sorting := m.Sorting
+	if sortBy := m.Views.Sort(); sortBy != "" {
+		sorting.SortBy = sortBy
+	}
+
 	prItems := make([]list.Item, 0)
-	for _, pr := range m.prs.Prs {
-		if m.Ignores.IsHidden(pr) || m.QuickFilters.IsHidden(pr) {
+	for _, pr := range m.Prs.Closed() {
+		changes := m.WhatChanged.WhatChanged(pr)
+		if m.Ignores.IsHidden(pr) || m.QuickFilters.IsHidden(pr) || m.Approvals.IsHidden(pr) || m.Query.IsHidden(pr, changes) || m.Views.IsHidden(pr, changes) {
 			continue
 		}
+		prItems = append(prItems, ClosedPullRequestItem{pr})
+	}
+
+	var header *SectionHeaderItem
+	for _, pr := range sorting.Sort(visiblePrs, m.WhatChanged) {
+		if group := sorting.Group(pr, m.WhatChanged.WhatChanged(pr)); group != "" && (header == nil || header.Name != group) {
+			header = &SectionHeaderItem{Name: group}
+			prItems = append(prItems, header)
+		}
+		if header != nil {
+			header.Count++
+		}
 
 		prItems = append(prItems, PullRequestItem{
 			pr,
 			m.WhatChanged.WhatChanged(pr),
 			m.Ignores.IsIgnored(pr),
+			m.localStatus.Get(pr),
+			m.Watch.Involvement(pr),
+			len(m.Approvals.StaleApprovers(pr)),
+			m.Approvals.IsMyApprovalStale(pr),
 		})
 	}
 	m.list.SetItems(prItems)
+	m.list.Title = listTitle(m)
 	if m.list.Cursor() >= len(prItems) {
 		m.list.Select(len(prItems) - 1)
 	}
@@ -125,29 +209,189 @@ func UpdateListView(m *rootModel) {
 	}
 }
 
+func listTitle(m *rootModel) string {
+	title := "Pull requests"
+	if m.QuickFilters.ShowMineOnly {
+		title = "My pull requests"
+	}
+	if m.Approvals.ShowMyStaleOnly {
+		title = "My stale approvals"
+	}
+	if m.Query.Query != "" {
+		query := m.Query.Query
+		for _, filter := range m.savedFilters {
+			if filter.Query == m.Query.Query {
+				query = filter.Name
+			}
+		}
+		title += ": " + query
+	}
+	if m.Prs.IsOffline() {
+		if m.Prs.IsLoaded() {
+			title += " · offline — data from " + TimeAgo(m.Prs.UpdatedOn)
+		} else {
+			title += " · offline — no data yet"
+		}
+	}
+	return title
+}
+
+func (m rootModel) headerView() string {
+	return m.Views.View()
+}
+
+func (m rootModel) footerView() string {
+	if m.prompt.IsActive() {
+		return m.prompt.View()
+	}
+	if m.Query.IsEditing() {
+		return m.Query.View()
+	}
+	return m.changesPopup.View()
+}
+
+func (m *rootModel) resize() {
+	h, v := docStyle.GetFrameSize()
+	extraHeight := lipgloss.Height(m.headerView())
+	if footer := m.footerView(); footer != "" {
+		extraHeight += lipgloss.Height(footer)
+	}
+	m.list.SetSize(m.width-h, m.height-v-extraHeight)
+	m.editForm.SetSize(m.width-h, m.height-v)
+}
+
+func (m rootModel) applySavedFilter(filter model.SavedFilter) tea.Cmd {
+	query := filter.Query
+	if m.Query.Query == filter.Query {
+		query = ""
+	}
+	cmd, err := m.Query.SetQuery(query)
+	if err != nil {
+		return NewErrorToast("Invalid filter " + filter.Name + ": " + err.Error())
+	}
+	return cmd
+}
+
 func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		h, v := docStyle.GetFrameSize()
-		m.list.SetSize(msg.Width-h, msg.Height-v)
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
 		return m, nil
 
 	case model.MsgUpdateListView:
 		UpdateListView(&m)
+		if m.Query.NeedsConflicts() || m.Views.NeedsConflicts() {
+			return m, m.conflicts.Check(m.Prs.Prs)
+		}
+		return m, nil
+
+	case msgOpenInTerminal:
+		return m, execInTerminal(msg)
+
+	case model.MsgEditDescription:
+		return m, editInEditor(msg.Text)
+
+	case model.MsgPrompt:
+		m.prompt, _ = m.prompt.Update(msg)
+		m.resize()
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.prompt.IsActive() {
+			var cmd tea.Cmd
+			m.prompt, cmd = m.prompt.Update(msg)
+			m.resize()
+			return m, cmd
+		}
+
+		if m.Query.IsEditing() {
+			var cmd tea.Cmd
+			m.Query, cmd = m.Query.Update(msg)
+			m.resize()
+			return m, cmd
+		}
+
+		if m.changesPopup.IsVisible() {
+			switch msg.String() {
+			case "ctrl+c":
+				m.quitting = true
+				return m, tea.Quit
+			case "d":
+				cmd := m.WhatChanged.DismissChanges(m.changesPopup.Pr())
+				m.changesPopup.Close()
+				m.resize()
+				return m, cmd
+			case "w", "esc", "q":
+				m.changesPopup.Close()
+				m.resize()
+			}
+			return m, nil
+		}
+
+		if m.Activity.IsVisible() {
+			switch msg.String() {
+			case "ctrl+c":
+				m.quitting = true
+				return m, tea.Quit
+			case "enter":
+				if event, ok := m.Activity.Selected(); ok && !m.Activity.IsFiltering() {
+					return m, OpenBrowser(event.Url)
+				}
+			}
+			var cmd tea.Cmd
+			m.Activity, cmd = m.Activity.Update(msg)
+			return m, cmd
+		}
+
+		if m.Reviewers.IsVisible() {
+			if msg.String() == "ctrl+c" {
+				m.quitting = true
+				return m, tea.Quit
+			}
+			var cmd tea.Cmd
+			m.Reviewers, cmd = m.Reviewers.Update(msg)
+			return m, cmd
+		}
+
+		if m.editForm.IsVisible() {
+			if msg.String() == "ctrl+c" {
+				m.quitting = true
+				return m, tea.Quit
+			}
+			var cmd tea.Cmd
+			m.editForm, cmd = m.editForm.Update(msg)
+			return m, cmd
+		}
+
+		if m.jobs.IsVisible() {
+			switch msg.String() {
+			case "ctrl+c":
+				m.quitting = true
+				return m, tea.Quit
+			case "J", "esc", "q":
+				m.jobs.ToggleVisible()
+			}
+			return m, nil
+		}
+
 		if m.list.FilterState() == list.Filtering {
 			break
 		}
 
+		for _, filter := range m.savedFilters {
+			if msg.String() == filter.Key {
+				return m, m.applySavedFilter(filter)
+			}
+		}
+
 		switch keypress := msg.String(); keypress {
 		case "ctrl+c":
 			m.quitting = true
-			return m, tea.Batch(m.dump, tea.Quit)
+			return m, tea.Quit
 
 		case "r":
-			return m, m.prs.StartLoadingPrs
+			return m, m.Prs.StartLoadingPrs
 
 		case ".":
 			cmd := m.Ignores.ToggleShowIgnored()
@@ -155,108 +399,347 @@ func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case "m":
 			cmd := m.QuickFilters.ToggleShowMineOnly()
-			if m.QuickFilters.ShowMineOnly {
-				m.list.Title = "My pull requests"
-			} else {
-				m.list.Title = "Pull requests"
+			return m, cmd
+
+		case "a":
+			cmd := m.Watch.ToggleShowAll()
+			return m, cmd
+
+		case "s":
+			cmd := m.Approvals.ToggleShowMyStaleOnly()
+			if m.Approvals.ShowMyStaleOnly {
+				return m, tea.Batch(cmd, NewInfoToast("Showing my approvals made before the last commit"))
+			}
+			return m, tea.Batch(cmd, NewInfoToast("Showing all pull requests"))
+
+		case "D":
+			cmd := m.QuickFilters.ToggleShowDrafts()
+			if m.QuickFilters.ShowDrafts {
+				return m, tea.Batch(cmd, NewInfoToast("Showing drafts"))
 			}
+			return m, tea.Batch(cmd, NewInfoToast("Hiding drafts of others"))
+
+		case "f":
+			cmd := m.Query.StartEditing()
+			m.resize()
 			return m, cmd
-		}
 
-		sel, ok := m.list.SelectedItem().(PullRequestItem)
-		if !ok {
+		case "F":
+			cmd, _ := m.Query.SetQuery("")
+			return m, cmd
+
+		case "X":
+			return m, CleanupWorktrees(m)
+
+		case "J":
+			m.jobs.ToggleVisible()
 			return m, nil
-		}
 
-		switch keypress := msg.String(); keypress {
-		case "i":
-			cmd := m.Ignores.ToggleIgnore(sel.Pr)
-			return m, cmd
+		case "A":
+			m.Activity.ToggleVisible()
+			return m, nil
 
-		case "d":
-			cmd := m.WhatChanged.DismissChanges(sel.Pr)
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			cmd := m.Views.Select(int(keypress[0] - '1'))
 			return m, cmd
 
-		case "u":
-			return m, CopyToClipboard(sel.Pr.Url, m)
+		case "o":
+			if sortBy := m.Views.Sort(); sortBy != "" {
+				m.Sorting.SortBy = sortBy
+			}
+			m.Views.CycleSort()
+			cmd := m.Sorting.CycleSortOrder()
+			return m, tea.Batch(cmd, NewInfoToast("Sorted by "+string(m.Sorting.SortBy)))
+
+		case "O":
+			cmd := m.Sorting.CycleGrouping()
+			return m, tea.Batch(cmd, NewInfoToast("Grouped by "+string(m.Sorting.GroupBy)))
+		}
+
+		if closed, ok := m.list.SelectedItem().(ClosedPullRequestItem); ok {
+			switch msg.String() {
+			case "d":
+				return m, m.Prs.DismissClosed(closed.Pr)
+			case "enter":
+				return m, OpenBrowser(closed.Pr.Url)
+			}
+		}
+
+		// Section headers and closed pull requests fall through, so that the list can still be navigated.
+		if sel, ok := m.list.SelectedItem().(PullRequestItem); ok {
+			switch keypress := msg.String(); keypress {
+			case "i":
+				cmd := m.Ignores.ToggleIgnore(sel.Pr)
+				return m, cmd
+
+			case "d":
+				cmd := m.WhatChanged.DismissChanges(sel.Pr)
+				return m, cmd
 
-		case "b":
-			return m, CopyToClipboard(sel.Pr.Branch, m)
+			case "w":
+				cmd := m.changesPopup.Open(sel.Pr, m.WhatChanged, m.Approvals)
+				m.resize()
+				return m, cmd
 
-		case "c":
-			return m, Checkout(sel.Pr, m)
+			case "W":
+				return m, ToggleWatch(sel.Pr, m)
 
-		case "P":
-			return m, PullOrigin(sel.Pr, m)
+			case "R":
+				return m, m.Reviewers.Open(sel.Pr)
 
-		case "enter":
-			cmd := m.WhatChanged.DismissChanges(sel.Pr)
-			return m, tea.Batch(OpenBrowser(sel.Pr.Url), cmd)
+			case "E":
+				return m, m.editForm.Open(sel.Pr)
+
+			case "u":
+				return m, CopyToClipboard(sel.Pr.Url, m)
+
+			case "b":
+				return m, CopyToClipboard(sel.Pr.Branch, m)
+
+			case "c":
+				return m, Checkout(sel.Pr, m)
+
+			case "P":
+				return m, PullOrigin(sel.Pr, m)
+
+			case "e":
+				return m, OpenEditor(sel.Pr, m)
+
+			case "t":
+				return m, OpenShell(sel.Pr, m)
+
+			case "enter":
+				cmd := m.WhatChanged.DismissChanges(sel.Pr)
+				return m, tea.Batch(OpenBrowser(sel.Pr.Url), cmd)
+			}
+
+			for _, action := range m.actions {
+				if msg.String() == action.Key {
+					return m, RunAction(action, sel.Pr, m)
+				}
+			}
 		}
 	}
 
-	var listCmd, prsCmd, autoUpdateCmd, whatChangedCmd, toastStreamCmd tea.Cmd
+	var listCmd, prsCmd, autoUpdateCmd, whatChangedCmd, toastStreamCmd, queryCmd, localStatusCmd, failedReposCmd, loadFailedCmd, closedCmd, conflictsCmd, reviewersCmd tea.Cmd
+	var jobsCmd, changesPopupCmd, activityCmd, approvalsCmd, editFormCmd tea.Cmd
 	m.list, listCmd = m.list.Update(msg)
-	m.prs, prsCmd = m.prs.Update(msg)
+	m.Query, queryCmd = m.Query.Update(msg)
+	m.localStatus, localStatusCmd = m.localStatus.Update(msg)
+	m.jobs, jobsCmd = m.jobs.Update(msg)
+	m.changesPopup, changesPopupCmd = m.changesPopup.Update(msg)
+	if m.changesPopup.IsVisible() {
+		m.resize()
+	}
+	m.Prs, prsCmd = m.Prs.Update(msg)
 	m.autoUpdate, autoUpdateCmd = m.autoUpdate.Update(msg)
 	m.WhatChanged, whatChangedCmd = m.WhatChanged.Update(msg)
+	m.Activity, activityCmd = m.Activity.Update(msg)
+	m.Approvals, approvalsCmd = m.Approvals.Update(msg)
+	m.Reviewers, reviewersCmd = m.Reviewers.Update(msg)
+	m.editForm, editFormCmd = m.editForm.Update(msg)
+	m.conflicts, conflictsCmd = m.conflicts.Update(msg)
 	m.async, toastStreamCmd = m.async.Update(msg)
 
-	return m, tea.Batch(listCmd, prsCmd, autoUpdateCmd, whatChangedCmd, toastStreamCmd)
+	switch msg := msg.(type) {
+	case model.MsgPrsLoaded:
+		localStatusCmd = LoadLocalStatuses(m)
+		if failed := m.Prs.FailedRepos(); len(failed) > 0 {
+			failedReposCmd = NewWarningToast("Could not load pull requests of " + failed.Error())
+		}
+	case model.MsgPrsLoadFailed:
+		loadFailedCmd = NewErrorToast("Could not load pull requests: " + msg.Err.Error())
+	case model.MsgPrsClosed:
+		closedCmd = NewInfoToast(closedPrsNotification(msg.Prs))
+	case msgLocalReposDiscovered:
+		m.localRepos = mergeLocalRepos(m.localRepos, msg.repos)
+		localStatusCmd = LoadLocalStatuses(m)
+	}
+
+	return m, tea.Batch(listCmd, prsCmd, autoUpdateCmd, whatChangedCmd, toastStreamCmd, queryCmd, localStatusCmd, failedReposCmd, loadFailedCmd, closedCmd, conflictsCmd, reviewersCmd,
+		jobsCmd, changesPopupCmd, activityCmd, approvalsCmd, editFormCmd)
+}
+
+func closedPrsNotification(closed []prs.PullRequest) string {
+	if len(closed) == 1 {
+		return fmt.Sprintf("%s: %s", closed[0].Title, model.ClosedChange(closed[0]))
+	}
+	return fmt.Sprintf("%d pull requests were merged or declined", len(closed))
 }
 
 func (m rootModel) View() string {
-	return m.list.View()
+	_, v := docStyle.GetFrameSize()
+	height := m.height - v
+	// A prompt takes the keys whichever pane is open, so it's drawn below the pane too.
+	prompt := ""
+	if m.prompt.IsActive() {
+		prompt = m.prompt.View()
+		height -= lipgloss.Height(prompt) + 1
+	}
+
+	pane := ""
+	switch {
+	case m.jobs.IsVisible():
+		pane = m.jobs.View(height)
+	case m.Activity.IsVisible():
+		pane = m.Activity.View(height)
+	case m.Reviewers.IsVisible():
+		pane = m.Reviewers.View(height)
+	case m.editForm.IsVisible():
+		pane = m.editForm.View()
+	}
+	if pane != "" {
+		if prompt != "" {
+			pane = lipgloss.JoinVertical(lipgloss.Left, pane, "", prompt)
+		}
+		return docStyle.Render(pane)
+	}
+
+	views := []string{m.headerView(), m.list.View()}
+	if footer := m.footerView(); footer != "" {
+		views = append(views, footer)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, views...)
 }
 
-func main() {
-	config, ok := ReadConfig()
-	if !ok {
-		CreateSampleConfig()
-		fmt.Println("Welcome to " + infoToastStyle.Render("bb") + ", a command-line pull requests dashboard!")
-		fmt.Println()
-		fmt.Println("To get started, please open the following file:")
-		fmt.Println(infoToastStyle.Render(configFilePath))
-		fmt.Println("and complete your configuration.")
-		os.Exit(1)
+func newRootModel(config Config, client prs.Client) (rootModel, error) {
+	views := config.Views
+	if views == nil {
+		views = model.DefaultViews
+	}
+	viewsModel := model.NewViewsModel(views)
+	if err := viewsModel.Validate(); err != nil {
+		return rootModel{}, err
 	}
-	c, ok := prs.CreateBitbucketClient(config.Bitbucket)
-	if !ok {
-		fmt.Println(errorToastStyle.Render("Could not connect to Bitbucket API."))
-		fmt.Println("Make sure that your credentials configured in the file:")
-		fmt.Println(infoToastStyle.Render(configFilePath))
-		fmt.Println("are valid and have the permissions " + successToastStyle.Render("account") + " and " + successToastStyle.Render("pullrequest") + ".")
 
-		os.Exit(1)
+	watch := model.NewWatchModel(config.Watch)
+	if err := watch.Validate(); err != nil {
+		return rootModel{}, err
+	}
+
+	if err := ValidateSavedFilters(config.SavedFilters); err != nil {
+		return rootModel{}, err
+	}
+
+	actions, err := ParseActions(config.Actions, config.SavedFilters)
+	if err != nil {
+		return rootModel{}, err
 	}
+
 	interval := time.Duration(config.UpdateIntervalMinutes) * time.Minute
 
 	const defaultWidth = 20
 
-	l := list.New(make([]list.Item, 0), model.NewItemDelegate(), defaultWidth, listHeight)
+	l := list.New(make([]list.Item, 0), model.NewItemDelegate(ActionKeyBindings(actions)), defaultWidth, listHeight)
 	l.Title = "Pull requests"
 	l.SetShowStatusBar(false)
 	l.Styles.Title = titleStyle
 	l.Styles.PaginationStyle = paginationStyle
 	l.Styles.HelpStyle = helpStyle
 
-	m := rootModel{
+	prsModel := model.NewPrsModel(client)
+	prsModel.SetRelevance(watch.IsRelevant)
+	activity := model.NewActivityModel(time.Duration(config.ActivityRetentionDays) * 24 * time.Hour)
+	activity.SetRelevance(watch.IsRelevant)
+
+	return rootModel{
 		list:         l,
-		prs:          model.NewPrsModel(c),
+		Prs:          prsModel,
 		Ignores:      model.NewIgnoresModel(),
 		autoUpdate:   model.NewAutoUpdateModel(interval),
 		WhatChanged:  model.NewWhatChangedModel(),
+		Activity:     activity,
+		Watch:        watch,
+		Approvals:    model.NewApprovalsModel(),
+		Reviewers:    model.NewReviewersModel(client, config.ReviewerGroups),
 		QuickFilters: model.NewQuickFiltersModel(),
+		Sorting:      model.NewSortingModel(),
+		Query:        model.NewQueryModel(),
+		Views:        viewsModel,
 		async:        model.NewAsyncModel(),
+		prompt:       model.NewPromptModel(),
+		localStatus:  model.NewLocalStatusModel(),
+		jobs:         model.NewJobsModel(),
+		changesPopup: model.NewChangesPopupModel(client),
+		editForm:     model.NewEditFormModel(client),
+		conflicts:    model.NewConflictsModel(client),
+		jobRunner:    NewJobRunner(),
+		client:       client,
 		localRepos:   config.LocalRepositoryPaths,
+		discovery:    discoverLocalReposCmd(config.DiscoveryRoots, config.Bitbucket.RepositoryEntries()),
+		checkoutMode: config.CheckoutMode,
+		worktreesDir: config.WorktreesPath,
+		savedFilters: config.SavedFilters,
+		actions:      actions,
+	}, nil
+}
+
+func main() {
+	config, err := ReadConfig()
+	if errors.Is(err, fs.ErrNotExist) {
+		CreateSampleConfig()
+		fmt.Println("Welcome to " + infoToastStyle.Render("bb") + ", a command-line pull requests dashboard!")
+		fmt.Println()
+		fmt.Println("To get started, please open the following file:")
+		fmt.Println(infoToastStyle.Render(configFilePath))
+		fmt.Println("and complete your configuration.")
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Println(errorToastStyle.Render(err.Error()))
+		fmt.Println("Please check your configuration in the file:")
+		fmt.Println(infoToastStyle.Render(configFilePath))
+		os.Exit(1)
+	}
+	if len(os.Args) > 1 && os.Args[1] == "discover" {
+		runDiscover(config, os.Args[2:])
+		return
+	}
+
+	httpClient := &http.Client{}
+	if dir := os.Getenv("BB_RECORD_FIXTURES"); dir != "" {
+		httpClient.Transport = prs.RecordingTransport{Dir: dir}
+	}
+	c, ok := prs.NewBitbucketClient(config.Bitbucket, httpClient)
+	if !ok {
+		fmt.Println(errorToastStyle.Render("Could not connect to Bitbucket API."))
+		fmt.Println("Make sure that your credentials configured in the file:")
+		fmt.Println(infoToastStyle.Render(configFilePath))
+		fmt.Println("are valid and have the permissions " + successToastStyle.Render("account") + " and " + successToastStyle.Render("pullrequest") + ".")
+
+		os.Exit(1)
+	}
+
+	m, err := newRootModel(config, c)
+	if err != nil {
+		fmt.Println(errorToastStyle.Render(err.Error()))
+		fmt.Println("Please check your configuration in the file:")
+		fmt.Println(infoToastStyle.Render(configFilePath))
+		os.Exit(1)
 	}
 
-	m.load()
-	defer m.dump()
+	saveState := true
+	unlock, err := lockStateFile(stateFilePath)
+	if err != nil {
+		saveState = false
+		m.stateNotice = "Another instance of bb is running, changes won't be saved"
+	} else {
+		defer unlock()
+	}
+	if err := m.load(); err != nil {
+		saveState = saveState && !errors.Is(err, errStateTooNew)
+		m.stateNotice = "Could not load the saved state: " + err.Error()
+	}
 
-	if err := tea.NewProgram(m, tea.WithAltScreen()).Start(); err != nil {
+	finalModel, err := tea.NewProgram(m, tea.WithAltScreen()).StartReturningModel()
+	if err != nil {
 		fmt.Println("Error running program:", err)
 		os.Exit(1)
 	}
+	if saveState {
+		if err := finalModel.(rootModel).dump(); err != nil {
+			fmt.Println(errorToastStyle.Render("Could not save the state: " + err.Error()))
+		}
+	}
 
 }
@@ -0,0 +1,564 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hejmsdz/bb/model"
+	"github.com/hejmsdz/bb/prs"
+)
+
+func samplePrs() []prs.PullRequest {
+	now := time.Now()
+	return []prs.PullRequest{
+		{
+			Id: "1", Repo: "acme/api", Title: "Add login endpoint", Author: "Alice",
+			Branch: "login", TargetBranch: "main", LastCommit: "aaa",
+			AmIParticipating: true, UpdatedOn: now.Add(-time.Hour),
+		},
+		{
+			Id: "2", Repo: "acme/web", Title: "Fix header layout", Author: "Me",
+			Branch: "header", TargetBranch: "main", LastCommit: "bbb",
+			IsMine: true, ApprovedCount: 1, UpdatedOn: now.Add(-2 * time.Hour),
+		},
+	}
+}
+
+func assertShown(t *testing.T, h *harness, titles ...string) {
+	t.Helper()
+	view := h.view()
+	for _, title := range titles {
+		if !strings.Contains(view, title) {
+			t.Errorf("expected %q in view:\n%s", title, view)
+		}
+	}
+}
+
+func assertHidden(t *testing.T, h *harness, titles ...string) {
+	t.Helper()
+	view := h.view()
+	for _, title := range titles {
+		if strings.Contains(view, title) {
+			t.Errorf("expected %q not to be in view:\n%s", title, view)
+		}
+	}
+}
+
+func TestShowsPullRequests(t *testing.T) {
+	h := newHarness(t, prs.NewFakeClient(samplePrs()...), testConfig())
+	assertShown(t, h, "Add login endpoint", "Fix header layout", "Awaiting my review")
+}
+
+func TestShowMineOnly(t *testing.T) {
+	h := newHarness(t, prs.NewFakeClient(samplePrs()...), testConfig())
+
+	h.press("m")
+	assertShown(t, h, "My pull requests", "Fix header layout")
+	assertHidden(t, h, "Add login endpoint")
+
+	h.press("m")
+	assertShown(t, h, "Add login endpoint")
+}
+
+func TestIgnoreAndShowIgnored(t *testing.T) {
+	h := newHarness(t, prs.NewFakeClient(samplePrs()...), testConfig())
+
+	h.press("i")
+	assertHidden(t, h, "Add login endpoint")
+
+	h.press(".")
+	assertShown(t, h, "Add login endpoint")
+}
+
+func TestQueryFilter(t *testing.T) {
+	h := newHarness(t, prs.NewFakeClient(samplePrs()...), testConfig())
+
+	h.press("f")
+	h.typeText("repo:acme/web")
+	h.press("enter")
+	assertShown(t, h, "Fix header layout")
+	assertHidden(t, h, "Add login endpoint")
+
+	h.press("F")
+	assertShown(t, h, "Add login endpoint")
+}
+
+func TestFilterByConflicts(t *testing.T) {
+	client := prs.NewFakeClient(samplePrs()...)
+	client.SetConflicts("acme/api/1", true)
+	h := newHarness(t, client, testConfig())
+
+	h.press("f")
+	h.typeText("has:conflicts")
+	h.press("enter")
+	assertShown(t, h, "Add login endpoint")
+	assertHidden(t, h, "Fix header layout")
+}
+
+func TestSavedFilterKeys(t *testing.T) {
+	tests := []struct {
+		filters []model.SavedFilter
+		err     string
+	}{
+		{[]model.SavedFilter{{Name: "Mine", Query: "author:me"}}, `saved filter "Mine" has no key`},
+		{[]model.SavedFilter{{Name: "Mine", Key: "r", Query: "author:me"}}, `saved filter "Mine": the key "r" is taken by bb`},
+		{[]model.SavedFilter{{Name: "Mine", Key: "ctrl+r", Query: "author:me"}, {Name: "API", Key: "ctrl+r", Query: "repo:api"}},
+			`saved filter "API": the key "ctrl+r" is taken by "Mine"`},
+		{[]model.SavedFilter{{Name: "Mine", Key: "ctrl+r", Query: "color:red"}}, `saved filter "Mine": unknown field color:`},
+	}
+	for _, test := range tests {
+		config := testConfig()
+		config.SavedFilters = test.filters
+		if _, err := newRootModel(config, prs.NewFakeClient()); err == nil || err.Error() != test.err {
+			t.Errorf("expected the error %q, got %v", test.err, err)
+		}
+	}
+}
+
+func TestActionKeys(t *testing.T) {
+	filters := []model.SavedFilter{{Name: "Mine", Key: "ctrl+r", Query: "author:me"}}
+	tests := []struct {
+		actions []Action
+		err     string
+	}{
+		{[]Action{{Name: "test", Command: "make test"}}, `action "test" has no key`},
+		{[]Action{{Name: "test", Key: "s", Command: "make test"}}, `action "test": the key "s" is taken by bb`},
+		{[]Action{{Name: "test", Key: "ctrl+r", Command: "make test"}}, `action "test": the key "ctrl+r" is taken by saved filter "Mine"`},
+		{[]Action{{Name: "test", Key: "T", Command: "make test"}, {Name: "lint", Key: "T", Command: "make lint"}},
+			`action "lint": the key "T" is taken by action "test"`},
+	}
+	for _, test := range tests {
+		if _, err := ParseActions(test.actions, filters); err == nil || err.Error() != test.err {
+			t.Errorf("expected the error %q, got %v", test.err, err)
+		}
+	}
+}
+
+func TestInvalidQueryKeepsEditing(t *testing.T) {
+	h := newHarness(t, prs.NewFakeClient(samplePrs()...), testConfig())
+
+	h.press("f")
+	h.typeText("color:red")
+	h.press("enter")
+	if !h.model.Query.IsEditing() {
+		t.Error("expected the query input to stay open")
+	}
+	assertShown(t, h, "unknown field color:")
+
+	h.press("esc")
+	if h.model.Query.IsEditing() || h.model.Query.Query != "" {
+		t.Errorf("expected the query to be unchanged, got %q", h.model.Query.Query)
+	}
+}
+
+func TestSwitchViews(t *testing.T) {
+	h := newHarness(t, prs.NewFakeClient(samplePrs()...), testConfig())
+
+	h.press("2")
+	assertShown(t, h, "Add login endpoint")
+	assertHidden(t, h, "Fix header layout")
+
+	h.press("1")
+	assertShown(t, h, "Add login endpoint", "Fix header layout")
+}
+
+func TestRefreshShowsWhatChanged(t *testing.T) {
+	client := prs.NewFakeClient(samplePrs()...)
+	h := newHarness(t, client, testConfig())
+
+	updated := samplePrs()
+	updated[0].CommentsCount = 3
+	client.SetPullRequests(updated...)
+	h.press("r")
+
+	if client.Calls() != 2 {
+		t.Errorf("expected 2 calls to the client, got %d", client.Calls())
+	}
+	assertShown(t, h, "🔔", "commented")
+
+	h.press("d")
+	assertHidden(t, h, "🔔")
+}
+
+func TestCycleSortOrder(t *testing.T) {
+	h := newHarness(t, prs.NewFakeClient(samplePrs()...), testConfig())
+
+	h.press("o")
+	if h.model.Sorting.SortBy != model.SortByCreated {
+		t.Errorf("expected sorting by %s, got %s", model.SortByCreated, h.model.Sorting.SortBy)
+	}
+	assertShown(t, h, "Sorted by created")
+}
+
+func TestCycleSortOrderOfView(t *testing.T) {
+	config := testConfig()
+	config.Views = []model.View{{Name: "By repository", Sort: model.SortByRepo}}
+	h := newHarness(t, prs.NewFakeClient(samplePrs()...), config)
+
+	h.press("2", "o")
+	if h.model.Sorting.SortBy != model.SortByAuthor {
+		t.Errorf("expected sorting by %s, got %s", model.SortByAuthor, h.model.Sorting.SortBy)
+	}
+	if sortBy := h.model.Views.Sort(); sortBy != "" {
+		t.Errorf("expected the view sort to be overridden, got %s", sortBy)
+	}
+	assertShown(t, h, "Sorted by author")
+
+	h.press("1", "2")
+	if sortBy := h.model.Views.Sort(); sortBy != model.SortByRepo {
+		t.Errorf("expected the view sort to be restored, got %s", sortBy)
+	}
+}
+
+func TestPromptIsShownOverJobs(t *testing.T) {
+	h := newHarness(t, prs.NewFakeClient(samplePrs()...), testConfig())
+
+	h.press("J")
+	h.run(model.Confirm("Push the rebased login to origin?", nil, nil))
+	h.settle()
+	assertShown(t, h, "Jobs (0 running)", "Push the rebased login to origin?")
+}
+
+func TestNavigatePastSectionHeader(t *testing.T) {
+	h := newHarness(t, prs.NewFakeClient(samplePrs()...), testConfig())
+
+	h.press("O")
+	if _, ok := h.model.list.SelectedItem().(*SectionHeaderItem); !ok {
+		t.Fatalf("expected the cursor on a section header, got %T", h.model.list.SelectedItem())
+	}
+	h.press("down")
+	if _, ok := h.model.list.SelectedItem().(PullRequestItem); !ok {
+		t.Errorf("expected the cursor to move to a pull request, got %T", h.model.list.SelectedItem())
+	}
+	h.press("j", "j")
+	if index := h.model.list.Index(); index != 3 {
+		t.Errorf("expected the cursor to move past the next header, got index %d", index)
+	}
+}
+
+func TestOfflineKeepsLastSnapshot(t *testing.T) {
+	client := prs.NewFakeClient(samplePrs()...)
+	h := newHarness(t, client, testConfig())
+
+	client.SetError(errors.New("no network"))
+	h.press("r")
+	assertShown(t, h, "offline — data from just now", "Add login endpoint", "Fix header layout")
+
+	client.SetError(nil)
+	h.press("r")
+	assertHidden(t, h, "offline")
+}
+
+func TestStartsWithSnapshotWhenOffline(t *testing.T) {
+	h := newHarness(t, prs.NewFakeClient(samplePrs()...), testConfig())
+
+	offline := prs.NewFakeClient()
+	offline.SetError(errors.New("no network"))
+	h = h.restart(offline, testConfig())
+	assertShown(t, h, "offline — data from just now", "Add login endpoint", "Fix header layout")
+}
+
+func TestWhatChangedPopup(t *testing.T) {
+	client := prs.NewFakeClient(samplePrs()...)
+	h := newHarness(t, client, testConfig())
+
+	updated := samplePrs()
+	updated[0].LastCommit = "ccc"
+	updated[0].CommentsCount = 2
+	updated[0].ApprovedCount = 1
+	updated[0].Approvers = []string{"Carol"}
+	updated[0].UpdatedOn = time.Now()
+	client.SetPullRequests(updated...)
+	client.SetCommits("acme/api/1", prs.Commit{Hash: "ccc", Author: "Bob"}, prs.Commit{Hash: "aaa", Author: "Alice"})
+	client.SetComments("acme/api/1", prs.Comment{Author: "Dave", CreatedOn: time.Now()}, prs.Comment{Author: "Erin", CreatedOn: time.Now()})
+	h.press("r")
+
+	h.press("w")
+	assertShown(t, h, "What changed in Add login endpoint", "1 new commit by Bob, 2 new comments by Dave, Erin, Carol approved")
+
+	h.press("d")
+	assertHidden(t, h, "What changed", "🔔")
+}
+
+func TestActivityFeed(t *testing.T) {
+	client := prs.NewFakeClient(samplePrs()...)
+	h := newHarness(t, client, testConfig())
+
+	updated := samplePrs()
+	updated[0].CommentsCount = 2
+	updated[1].ApprovedCount = 2
+	client.SetPullRequests(updated...)
+	h.press("r", "A")
+	assertShown(t, h, "Activity", "Add login endpoint — 2 new comments", "Fix header layout — approvals 1 → 2")
+
+	h.press("/")
+	h.typeText("login")
+	h.press("enter")
+	assertShown(t, h, "Add login endpoint — 2 new comments")
+	assertHidden(t, h, "Fix header layout")
+
+	h.press("esc")
+	assertShown(t, h, "Pull requests")
+}
+
+func TestNewAndClosedPullRequests(t *testing.T) {
+	client := prs.NewFakeClient(samplePrs()...)
+	h := newHarness(t, client, testConfig())
+
+	opened := prs.PullRequest{
+		Id: "3", Repo: "acme/api", Title: "Add logout endpoint", Author: "Bob",
+		AmIParticipating: true, UpdatedOn: time.Now(),
+	}
+	client.SetPullRequests(append(samplePrs(), opened)...)
+	client.Close("acme/web/2", prs.StateMerged, "Alice")
+	client.SetPullRequests(samplePrs()[0], opened)
+	h.press("r")
+	assertShown(t, h, "Add logout endpoint [new]", "Fix header layout [merged by Alice]")
+
+	h.press("d")
+	assertHidden(t, h, "[merged by Alice]")
+
+	h.press("r")
+	assertShown(t, h, "Add logout endpoint [new]")
+}
+
+func TestNavigatePastClosedPullRequest(t *testing.T) {
+	client := prs.NewFakeClient(samplePrs()...)
+	h := newHarness(t, client, testConfig())
+
+	client.Close("acme/web/2", prs.StateMerged, "Alice")
+	h.press("r")
+	if _, ok := h.model.list.SelectedItem().(ClosedPullRequestItem); !ok {
+		t.Fatalf("expected the cursor on the closed pull request, got %T", h.model.list.SelectedItem())
+	}
+	h.press("down")
+	if sel, ok := h.model.list.SelectedItem().(PullRequestItem); !ok || sel.Pr.Uid() != "acme/api/1" {
+		t.Errorf("expected the cursor to move to the open pull request, got %v", h.model.list.SelectedItem())
+	}
+}
+
+func TestClosedPullRequestsAreFiltered(t *testing.T) {
+	client := prs.NewFakeClient(samplePrs()...)
+	h := newHarness(t, client, testConfig())
+
+	client.Close("acme/web/2", prs.StateMerged, "Alice")
+	h.press("r")
+	assertShown(t, h, "Fix header layout [merged by Alice]")
+
+	h.press("f")
+	h.typeText("repo:acme/api")
+	h.press("enter")
+	assertHidden(t, h, "[merged by Alice]")
+}
+
+func TestWatchPullRequest(t *testing.T) {
+	others := prs.PullRequest{
+		Id: "5", Repo: "acme/web", Title: "Rework caching", Author: "Bob",
+		Branch: "cache", TargetBranch: "main", UpdatedOn: time.Now(),
+	}
+	client := prs.NewFakeClient(append(samplePrs(), others)...)
+	h := newHarness(t, client, testConfig())
+	assertHidden(t, h, "Rework caching")
+
+	h.press("a")
+	assertShown(t, h, "Rework caching")
+
+	h.press("W")
+	h.press("a")
+	assertShown(t, h, "👁 Rework caching")
+}
+
+func TestWatchRules(t *testing.T) {
+	release := prs.PullRequest{
+		Id: "6", Repo: "acme/web", Title: "Backport fix", Author: "Bob",
+		Branch: "backport", TargetBranch: "release/2.0", UpdatedOn: time.Now(),
+	}
+	config := testConfig()
+	config.Watch = []model.WatchRule{{Target: "release/*"}}
+	h := newHarness(t, prs.NewFakeClient(append(samplePrs(), release)...), config)
+	assertShown(t, h, "👁 Backport fix")
+}
+
+func TestDrafts(t *testing.T) {
+	draft := prs.PullRequest{
+		Id: "7", Repo: "acme/api", Title: "Experiment with caching", Author: "Bob",
+		AmIParticipating: true, IsDraft: true, UpdatedOn: time.Now(),
+	}
+	client := prs.NewFakeClient(append(samplePrs(), draft)...)
+	h := newHarness(t, client, testConfig())
+	assertHidden(t, h, "Experiment with caching")
+
+	h.press("D")
+	assertShown(t, h, "[draft] Experiment with caching")
+
+	draft.IsDraft = false
+	client.SetPullRequests(append(samplePrs(), draft)...)
+	h.press("D", "r")
+	assertShown(t, h, "Experiment with caching [readyForReview]")
+}
+
+func TestManageReviewers(t *testing.T) {
+	client := prs.NewFakeClient(samplePrs()...)
+	client.SetMembers("acme",
+		prs.User{AccountId: "1", DisplayName: "Alice"},
+		prs.User{AccountId: "2", DisplayName: "Bob"},
+		prs.User{AccountId: "3", DisplayName: "Carol"},
+	)
+	config := testConfig()
+	config.ReviewerGroups = map[string][]string{"backend": {"Bob", "carol"}}
+	h := newHarness(t, client, config)
+
+	h.press("f")
+	h.typeText("repo:acme/web")
+	h.press("enter", "R")
+	assertShown(t, h, "Reviewers of Fix header layout", "[ ] 👥 backend: Bob, Carol", "[ ] Alice")
+
+	h.typeText("b")
+	h.press("down", "enter")
+	assertShown(t, h, "[-] 👥 backend: Bob, Carol", "[x] Bob")
+
+	h.press("up", "enter")
+	assertShown(t, h, "[x] 👥 backend: Bob, Carol")
+
+	prList, _ := client.GetAllPullRequests()
+	if reviewers := prList[1].Reviewers; len(reviewers) != 2 {
+		t.Errorf("expected Bob and Carol to review, got %v", reviewers)
+	}
+
+	h.press("esc")
+	assertShown(t, h, "Fix header layout")
+	assertHidden(t, h, "Reviewers of")
+}
+
+func TestToggleReviewerKeepsOthersAddedMeanwhile(t *testing.T) {
+	alice, bob := prs.User{AccountId: "1", DisplayName: "Alice"}, prs.User{AccountId: "2", DisplayName: "Bob"}
+	client := prs.NewFakeClient(samplePrs()...)
+	client.SetMembers("acme", alice, bob)
+	h := newHarness(t, client, testConfig())
+
+	h.press("f")
+	h.typeText("repo:acme/web")
+	h.press("enter", "R")
+	client.SetReviewers(samplePrs()[1], []prs.User{alice})
+
+	h.typeText("bob")
+	h.press("enter")
+	prList, _ := client.GetAllPullRequests()
+	if reviewers := prList[1].Reviewers; len(reviewers) != 2 {
+		t.Errorf("expected Alice to stay along with Bob, got %v", reviewers)
+	}
+}
+
+func TestEditPullRequest(t *testing.T) {
+	client := prs.NewFakeClient(samplePrs()...)
+	client.SetBranches("acme/web", "main", "header", "release/2.0", "release/1.9")
+	h := newHarness(t, client, testConfig())
+	calls := client.Calls()
+
+	h.press("f")
+	h.typeText("repo:acme/web")
+	h.press("enter", "E")
+	assertShown(t, h, "Edit acme/web#2", "> main", "release/2.0")
+	assertHidden(t, h, "  header")
+
+	h.typeText(" on mobile")
+	h.press("tab", "tab")
+	h.typeText("2.0")
+	h.press("enter")
+	assertShown(t, h, "Fix header layout on mobile")
+	assertHidden(t, h, "Edit acme/web#2")
+	if client.Calls() != calls {
+		t.Error("expected the pull request to be updated without reloading all of them")
+	}
+
+	prList, _ := client.GetAllPullRequests()
+	if prList[1].TargetBranch != "release/2.0" {
+		t.Errorf("expected the pull request to target release/2.0, got %s", prList[1].TargetBranch)
+	}
+}
+
+func TestStaleApprovals(t *testing.T) {
+	prList := samplePrs()
+	prList[0].MyReview = prs.Approved
+	prList[0].ApprovedCount = 2
+	prList[0].Approvers = []string{"Me", "Bob"}
+	prList[0].ApprovedBy = []prs.User{{AccountId: "me", DisplayName: "Me"}, {AccountId: "bob", DisplayName: "Bob"}}
+	client := prs.NewFakeClient(prList...)
+	h := newHarness(t, client, testConfig())
+	assertHidden(t, h, "stale")
+
+	prList[0].LastCommit = "ccc"
+	client.SetPullRequests(prList...)
+	h.press("r")
+	assertShown(t, h, "2 (2 stale)", "✅ stale")
+
+	h.press("s")
+	assertShown(t, h, "My stale approvals", "Add login endpoint")
+	assertHidden(t, h, "Fix header layout")
+
+	h.press("s", "w")
+	assertShown(t, h, "Approved before the last commit: Me, Bob")
+}
+
+func TestEditFormRefusesToSaveWhenNotLoaded(t *testing.T) {
+	client := prs.NewFakeClient(samplePrs()...)
+	h := newHarness(t, client, testConfig())
+
+	h.press("f")
+	h.typeText("repo:acme/web")
+	h.press("enter")
+	client.SetError(errors.New("503 Service Unavailable"))
+	h.press("E")
+	assertShown(t, h, "could not load the pull request: 503 Service Unavailable")
+
+	h.typeText(" on mobile")
+	h.press("ctrl+s")
+	assertShown(t, h, "Edit acme/web#2", "reopen the form to try again")
+
+	client.SetError(nil)
+	prList, _ := client.GetAllPullRequests()
+	if prList[1].Title != "Fix header layout" {
+		t.Errorf("expected the pull request not to be updated, got %q", prList[1].Title)
+	}
+}
+
+func TestFailedRepositoryKeepsItsPullRequests(t *testing.T) {
+	client := prs.NewFakeClient(samplePrs()...)
+	h := newHarness(t, client, testConfig())
+
+	client.SetRepositoryError("acme/web", errors.New("403 Forbidden"))
+	h.press("r")
+	assertShown(t, h, "Add login endpoint", "Fix header layout", "Could not load pull requests of acme/web: 403 Forbidden")
+	assertHidden(t, h, "offline", "merged", "declined")
+}
+
+func TestDiscoveredRepositoriesKeepConfiguredPaths(t *testing.T) {
+	config := testConfig()
+	config.LocalRepositoryPaths = map[string]string{"acme/api": "/configured/api"}
+	h := newHarness(t, prs.NewFakeClient(samplePrs()...), config)
+
+	h.send(msgLocalReposDiscovered{map[string]string{"acme/api": "/found/api", "acme/web": "/found/web"}})
+	h.settle()
+	if got := h.model.localRepos["acme/api"]; got != "/configured/api" {
+		t.Errorf("expected the configured path of acme/api to be kept, got %q", got)
+	}
+	if got := h.model.localRepos["acme/web"]; got != "/found/web" {
+		t.Errorf("expected the discovered path of acme/web to be added, got %q", got)
+	}
+}
+
+func TestBackgroundActionLogsItsOutputInTheJob(t *testing.T) {
+	config := testConfig()
+	config.Actions = []Action{{Name: "greet", Key: "x", Command: "echo hello from the action"}}
+	h := newHarness(t, prs.NewFakeClient(samplePrs()...), config)
+
+	h.press("x")
+	if got := strings.Join(h.toasts, " | "); got != "greet finished" {
+		t.Errorf("expected only the final status to be toasted, got %q", got)
+	}
+
+	h.press("J")
+	assertShown(t, h, "[done] greet", "hello from the action")
+}
@@ -0,0 +1,227 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hejmsdz/bb/prs"
+)
+
+const changeOpened = "opened"
+
+// ClosedChange describes how the pull request was closed, e.g. "merged by Alice".
+func ClosedChange(pr prs.PullRequest) Change {
+	change := Change{Kind: strings.ToLower(string(pr.State))}
+	if pr.ClosedBy != "" {
+		change.Users = []string{pr.ClosedBy}
+	}
+	return change
+}
+
+// ActivityEvent is a change observed between two successive loads of pull requests,
+// or the final state of a pull request which disappeared from the list.
+type ActivityEvent struct {
+	Time   time.Time
+	Pr     prs.Uid
+	Title  string
+	Url    string
+	Change Change
+}
+
+func (e ActivityEvent) String() string {
+	return fmt.Sprintf("%s %s — %s", e.Pr, e.Title, e.Change)
+}
+
+// ActivityModel keeps a feed of every change seen since the previous load, for the retention period.
+type ActivityModel struct {
+	Events []ActivityEvent
+	// seen is the snapshot of the previous load, nil until the first one. It is not saved,
+	// what changed while bb was not running is shown by WhatChangedModel instead.
+	seen       map[prs.Uid]prs.PullRequest
+	retention  time.Duration
+	isRelevant func(prs.PullRequest) bool
+	visible    bool
+	cursor     int
+	filtering  bool
+	filter     textinput.Model
+}
+
+var (
+	activityTitleStyle    = lipgloss.NewStyle().Bold(true)
+	activityTimeStyle     = lipgloss.NewStyle().Faint(true)
+	activitySelectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Bold(true)
+)
+
+func NewActivityModel(retention time.Duration) ActivityModel {
+	filter := textinput.New()
+	filter.Prompt = "/"
+	return ActivityModel{
+		Events:    make([]ActivityEvent, 0),
+		retention: retention,
+		filter:    filter,
+	}
+}
+
+// SetRelevance limits the pull requests whose changes are recorded.
+func (m *ActivityModel) SetRelevance(isRelevant func(prs.PullRequest) bool) {
+	m.isRelevant = isRelevant
+}
+
+func (m ActivityModel) IsVisible() bool {
+	return m.visible
+}
+
+func (m *ActivityModel) ToggleVisible() {
+	m.visible = !m.visible
+	m.cursor = 0
+}
+
+// IsFiltering tells whether keys are typed into the filter.
+func (m ActivityModel) IsFiltering() bool {
+	return m.filtering
+}
+
+// Filtered returns the events matching the filter, newest first.
+func (m ActivityModel) Filtered() []ActivityEvent {
+	query := strings.ToLower(m.filter.Value())
+	events := make([]ActivityEvent, 0, len(m.Events))
+	for i := len(m.Events) - 1; i >= 0; i-- {
+		if strings.Contains(strings.ToLower(m.Events[i].String()), query) {
+			events = append(events, m.Events[i])
+		}
+	}
+	return events
+}
+
+func (m ActivityModel) Selected() (ActivityEvent, bool) {
+	events := m.Filtered()
+	if m.cursor >= len(events) {
+		return ActivityEvent{}, false
+	}
+	return events[m.cursor], true
+}
+
+func (m ActivityModel) record(prList []prs.PullRequest, at time.Time) ActivityModel {
+	if m.seen != nil {
+		for _, pr := range prList {
+			if m.isRelevant != nil && !m.isRelevant(pr) {
+				continue
+			}
+			event := ActivityEvent{Time: at, Pr: pr.Uid(), Title: pr.Title, Url: pr.Url}
+			oldPr, wasSeen := m.seen[pr.Uid()]
+			if !wasSeen {
+				event.Change = Change{Kind: changeOpened, Users: []string{pr.Author}}
+				m.Events = append(m.Events, event)
+				continue
+			}
+			for _, change := range findUpdates(oldPr, pr) {
+				event.Change = change
+				m.Events = append(m.Events, event)
+			}
+		}
+	}
+
+	m.seen = make(map[prs.Uid]prs.PullRequest)
+	for _, pr := range prList {
+		m.seen[pr.Uid()] = pr
+	}
+	m.prune(at)
+	return m
+}
+
+// prune forgets the events older than the retention period.
+func (m *ActivityModel) prune(now time.Time) {
+	if m.retention <= 0 {
+		return
+	}
+	keep := 0
+	for keep < len(m.Events) && now.Sub(m.Events[keep].Time) > m.retention {
+		keep++
+	}
+	m.Events = m.Events[keep:]
+}
+
+func (m ActivityModel) Update(msg tea.Msg) (ActivityModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case MsgPrsLoaded:
+		return m.record(msg.prs, msg.updatedOn), nil
+
+	case MsgPrsClosed:
+		for _, pr := range msg.Prs {
+			m.Events = append(m.Events, ActivityEvent{msg.At, pr.Uid(), pr.Title, pr.Url, ClosedChange(pr)})
+		}
+
+	case tea.KeyMsg:
+		if !m.visible {
+			return m, nil
+		}
+		if m.filtering {
+			switch msg.String() {
+			case "enter", "esc":
+				m.filtering = false
+				m.filter.Blur()
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.filter, cmd = m.filter.Update(msg)
+			m.cursor = 0
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "A", "esc", "q":
+			m.ToggleVisible()
+		case "/":
+			m.filtering = true
+			return m, m.filter.Focus()
+		case "j", "down":
+			if m.cursor < len(m.Filtered())-1 {
+				m.cursor++
+			}
+		case "k", "up":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "g", "home":
+			m.cursor = 0
+		case "G", "end":
+			m.cursor = len(m.Filtered()) - 1
+		}
+	}
+	return m, nil
+}
+
+// View renders the newest events first, scrolled to keep the cursor within height.
+func (m ActivityModel) View(height int) string {
+	title := "Activity"
+	if m.retention > 0 {
+		title += fmt.Sprintf(" · last %d days", int(m.retention.Hours()/24))
+	}
+	lines := []string{activityTitleStyle.Render(title)}
+	if m.filtering || m.filter.Value() != "" {
+		lines = append(lines, m.filter.View())
+	}
+	lines = append(lines, activityTimeStyle.Render("/ filter • enter open in browser • esc close"), "")
+
+	events := m.Filtered()
+	if len(events) == 0 {
+		lines = append(lines, "Nothing happened yet.")
+	}
+	visible := height - len(lines)
+	first := 0
+	if visible > 0 && m.cursor >= visible {
+		first = m.cursor - visible + 1
+	}
+	for i := first; i < len(events) && len(lines) < height; i++ {
+		line := events[i].String()
+		if i == m.cursor {
+			line = activitySelectedStyle.Render(line)
+		}
+		lines = append(lines, activityTimeStyle.Render(events[i].Time.Format("Jan 2 15:04"))+"  "+line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hejmsdz/bb/prs"
+)
+
+func TestActivityRecord(t *testing.T) {
+	start := time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC)
+	login := prs.PullRequest{Id: "1", Repo: "acme/api", Title: "Add login", Author: "Alice", LastCommit: "aaa"}
+	header := prs.PullRequest{Id: "2", Repo: "acme/web", Title: "Fix header", Author: "Bob"}
+
+	m := NewActivityModel(7 * 24 * time.Hour)
+	m = m.record([]prs.PullRequest{login}, start)
+	if len(m.Events) != 0 {
+		t.Fatalf("expected no events after the first load, got %v", m.Events)
+	}
+
+	login.LastCommit = "bbb"
+	m = m.record([]prs.PullRequest{login, header}, start.Add(time.Hour))
+	login.State, login.ClosedBy = prs.StateMerged, "Carol"
+	m = m.record([]prs.PullRequest{header}, start.Add(2*time.Hour))
+	m, _ = m.Update(MsgPrsClosed{[]prs.PullRequest{login}, start.Add(2 * time.Hour)})
+	if closedAt := m.Events[2].Time; !closedAt.Equal(start.Add(2 * time.Hour)) {
+		t.Errorf("expected the closing to be dated by the load, got %s", closedAt)
+	}
+
+	want := []string{
+		"acme/api/1 Add login — new commits",
+		"acme/web/2 Fix header — opened by Bob",
+		"acme/api/1 Add login — merged by Carol",
+	}
+	assertEvents(t, m.Events, want)
+
+	m = m.record([]prs.PullRequest{header}, start.Add(7*24*time.Hour+90*time.Minute))
+	assertEvents(t, m.Events, want[2:])
+}
+
+func assertEvents(t *testing.T, events []ActivityEvent, want []string) {
+	t.Helper()
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %v", len(want), events)
+	}
+	for i, event := range events {
+		if event.String() != want[i] {
+			t.Errorf("event %d: expected %q, got %q", i, want[i], event.String())
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package model
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hejmsdz/bb/prs"
+)
+
+// ApprovalsModel remembers the last commit of each pull request at the time an approval was first seen,
+// so that approvals given before the latest push can be told apart. Approvals which were already there
+// the first time bb saw the pull request are assumed to be up to date.
+type ApprovalsModel struct {
+	// Commits maps the approvers of each pull request, by their User.Key, to the commit they approved.
+	Commits map[prs.Uid]map[string]string
+	// MyCommits holds the commit I approved in each pull request.
+	MyCommits       map[prs.Uid]string
+	ShowMyStaleOnly bool
+}
+
+func NewApprovalsModel() ApprovalsModel {
+	return ApprovalsModel{
+		Commits:   make(map[prs.Uid]map[string]string),
+		MyCommits: make(map[prs.Uid]string),
+	}
+}
+
+// record replaces the snapshot with the approvals of the loaded pull requests, keeping the commits of known ones.
+func (m ApprovalsModel) record(prList []prs.PullRequest) ApprovalsModel {
+	commits := make(map[prs.Uid]map[string]string)
+	myCommits := make(map[prs.Uid]string)
+	for _, pr := range prList {
+		commits[pr.Uid()], myCommits[pr.Uid()] = m.approvedCommits(pr)
+		if myCommits[pr.Uid()] == "" {
+			delete(myCommits, pr.Uid())
+		}
+	}
+	m.Commits = commits
+	m.MyCommits = myCommits
+	return m
+}
+
+func (m ApprovalsModel) approvedCommits(pr prs.PullRequest) (map[string]string, string) {
+	known := m.Commits[pr.Uid()]
+	commits := make(map[string]string)
+	for _, approver := range pr.ApprovedBy {
+		commits[approver.Key()] = pr.LastCommit
+		if commit, ok := known[approver.Key()]; ok {
+			commits[approver.Key()] = commit
+		}
+	}
+
+	myCommit := ""
+	if pr.MyReview == prs.Approved {
+		myCommit = pr.LastCommit
+		if commit, ok := m.MyCommits[pr.Uid()]; ok {
+			myCommit = commit
+		}
+	}
+	return commits, myCommit
+}
+
+// StaleApprovers lists who approved the pull request before its last commit.
+func (m ApprovalsModel) StaleApprovers(pr prs.PullRequest) []string {
+	stale := make([]string, 0)
+	for _, approver := range pr.ApprovedBy {
+		if commit, ok := m.Commits[pr.Uid()][approver.Key()]; ok && commit != pr.LastCommit {
+			stale = append(stale, approver.DisplayName)
+		}
+	}
+	return stale
+}
+
+func (m ApprovalsModel) IsMyApprovalStale(pr prs.PullRequest) bool {
+	commit, ok := m.MyCommits[pr.Uid()]
+	return ok && pr.MyReview == prs.Approved && commit != pr.LastCommit
+}
+
+func (m ApprovalsModel) Prune(open map[prs.Uid]bool) {
+	for uid := range m.Commits {
+		if !open[uid] {
+			delete(m.Commits, uid)
+		}
+	}
+	for uid := range m.MyCommits {
+		if !open[uid] {
+			delete(m.MyCommits, uid)
+		}
+	}
+}
+
+func (m ApprovalsModel) IsHidden(pr prs.PullRequest) bool {
+	return m.ShowMyStaleOnly && !m.IsMyApprovalStale(pr)
+}
+
+func (m *ApprovalsModel) ToggleShowMyStaleOnly() tea.Cmd {
+	m.ShowMyStaleOnly = !m.ShowMyStaleOnly
+	return UpdateListView
+}
+
+func (m ApprovalsModel) Update(msg tea.Msg) (ApprovalsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case MsgPrsLoaded:
+		return m.record(msg.prs), nil
+
+	case MsgPrUpdated:
+		if m.Commits == nil || m.MyCommits == nil {
+			m.Commits, m.MyCommits = make(map[prs.Uid]map[string]string), make(map[prs.Uid]string)
+		}
+		commits, myCommit := m.approvedCommits(msg.Pr)
+		m.Commits[msg.Pr.Uid()] = commits
+		if myCommit != "" {
+			m.MyCommits[msg.Pr.Uid()] = myCommit
+		} else {
+			delete(m.MyCommits, msg.Pr.Uid())
+		}
+	}
+	return m, nil
+}
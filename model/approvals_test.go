@@ -0,0 +1,60 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hejmsdz/bb/prs"
+)
+
+var (
+	alice = prs.User{AccountId: "alice-1", DisplayName: "Alice"}
+	bob   = prs.User{AccountId: "bob-1", DisplayName: "Bob"}
+	me    = prs.User{AccountId: "me-1", DisplayName: "Me"}
+)
+
+func TestStaleApprovals(t *testing.T) {
+	pr := prs.PullRequest{Id: "1", Repo: "acme/api", LastCommit: "aaa", ApprovedBy: []prs.User{alice}}
+	m := NewApprovalsModel().record([]prs.PullRequest{pr})
+
+	pr.LastCommit = "bbb"
+	pr.ApprovedBy = []prs.User{alice, bob, me}
+	pr.MyReview = prs.Approved
+	m = m.record([]prs.PullRequest{pr})
+	if stale := m.StaleApprovers(pr); !reflect.DeepEqual(stale, []string{"Alice"}) {
+		t.Errorf("expected Alice's approval to be stale, got %v", stale)
+	}
+	if m.IsMyApprovalStale(pr) {
+		t.Error("expected my approval of the last commit not to be stale")
+	}
+
+	pr.LastCommit = "ccc"
+	m = m.record([]prs.PullRequest{pr})
+	if stale := m.StaleApprovers(pr); len(stale) != 3 || !m.IsMyApprovalStale(pr) {
+		t.Errorf("expected all approvals to be stale, got %v", stale)
+	}
+
+	pr.ApprovedBy = []prs.User{bob}
+	pr.MyReview = prs.NoReview
+	m = m.record([]prs.PullRequest{pr})
+	pr.ApprovedBy = []prs.User{alice, bob}
+	m = m.record([]prs.PullRequest{pr})
+	if stale := m.StaleApprovers(pr); !reflect.DeepEqual(stale, []string{"Bob"}) {
+		t.Errorf("expected Alice's renewed approval to be up to date, got %v", stale)
+	}
+	if m.IsMyApprovalStale(pr) {
+		t.Error("expected a withdrawn approval not to be stale")
+	}
+}
+
+func TestApprovalsSurviveRenames(t *testing.T) {
+	pr := prs.PullRequest{Id: "1", Repo: "acme/api", LastCommit: "aaa", ApprovedBy: []prs.User{alice}}
+	m := NewApprovalsModel().record([]prs.PullRequest{pr})
+
+	pr.LastCommit = "bbb"
+	pr.ApprovedBy = []prs.User{{AccountId: "alice-1", DisplayName: "Alice Smith"}}
+	m = m.record([]prs.PullRequest{pr})
+	if stale := m.StaleApprovers(pr); !reflect.DeepEqual(stale, []string{"Alice Smith"}) {
+		t.Errorf("expected the approval of the renamed user to stay stale, got %v", stale)
+	}
+}
@@ -34,7 +34,7 @@ func (m AutoUpdateModel) scheduleAutoUpdate() tea.Msg {
 
 func (m AutoUpdateModel) Update(msg tea.Msg) (AutoUpdateModel, tea.Cmd) {
 	switch msg.(type) {
-	case MsgPrsLoaded:
+	case MsgPrsLoaded, MsgPrsLoadFailed:
 		return m, tea.Batch(m.scheduleAutoUpdate, m.waitForAutoUpdate)
 	}
 	return m, nil
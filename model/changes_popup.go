@@ -0,0 +1,114 @@
+package model
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hejmsdz/bb/prs"
+)
+
+// ChangesPopupModel shows what changed in a pull request since its snapshot,
+// with the commit authors and commenters loaded from the API, and whose approvals are stale.
+type ChangesPopupModel struct {
+	client         prs.Client
+	pr             *prs.PullRequest
+	changes        []Change
+	staleApprovers []string
+	loading        bool
+	err            error
+}
+
+type msgChangeDetailsLoaded struct {
+	uid      prs.Uid
+	commits  []prs.Commit
+	comments []prs.Comment
+	err      error
+}
+
+var (
+	popupStyle      = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	popupTitleStyle = lipgloss.NewStyle().Bold(true)
+	popupHintStyle  = lipgloss.NewStyle().Faint(true)
+)
+
+func NewChangesPopupModel(client prs.Client) ChangesPopupModel {
+	return ChangesPopupModel{client: client}
+}
+
+func (m ChangesPopupModel) IsVisible() bool {
+	return m.pr != nil
+}
+
+func (m ChangesPopupModel) Pr() prs.PullRequest {
+	return *m.pr
+}
+
+func (m *ChangesPopupModel) Open(pr prs.PullRequest, whatChanged WhatChangedModel, approvals ApprovalsModel) tea.Cmd {
+	m.pr = &pr
+	m.changes = whatChanged.Changes(pr)
+	m.staleApprovers = approvals.StaleApprovers(pr)
+	m.err = nil
+	m.loading = false
+
+	needsDetails := false
+	for _, change := range m.changes {
+		needsDetails = needsDetails || change.Kind == changeCommitted || change.Kind == changeCommented
+	}
+	if !needsDetails {
+		return nil
+	}
+	m.loading = true
+	prevPr := whatChanged.PrevPrs[pr.Uid()]
+	client := m.client
+	return func() tea.Msg {
+		commits, err := client.GetCommits(pr, prevPr.LastCommit)
+		if err != nil {
+			return msgChangeDetailsLoaded{uid: pr.Uid(), err: err}
+		}
+		comments, err := client.GetComments(pr, prevPr.UpdatedOn)
+		return msgChangeDetailsLoaded{pr.Uid(), commits, comments, err}
+	}
+}
+
+func (m *ChangesPopupModel) Close() {
+	m.pr = nil
+	m.changes = nil
+}
+
+func (m ChangesPopupModel) Update(msg tea.Msg) (ChangesPopupModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case msgChangeDetailsLoaded:
+		if m.pr == nil || m.pr.Uid() != msg.uid {
+			return m, nil
+		}
+		m.loading = false
+		m.err = msg.err
+		if msg.err == nil {
+			m.changes = WithDetails(m.changes, msg.commits, msg.comments)
+		}
+	}
+	return m, nil
+}
+
+func (m ChangesPopupModel) View() string {
+	if m.pr == nil {
+		return ""
+	}
+	lines := []string{popupTitleStyle.Render("What changed in " + m.pr.Title)}
+	if len(m.changes) == 0 {
+		lines = append(lines, "Nothing changed since you last looked")
+	} else {
+		lines = append(lines, Summary(m.changes))
+	}
+	if len(m.staleApprovers) > 0 {
+		lines = append(lines, "Approved before the last commit: "+strings.Join(m.staleApprovers, ", "))
+	}
+	if m.loading {
+		lines = append(lines, popupHintStyle.Render("Loading commits and comments…"))
+	} else if m.err != nil {
+		lines = append(lines, popupHintStyle.Render("Could not load commits and comments: "+m.err.Error()))
+	}
+	lines = append(lines, popupHintStyle.Render("d dismiss • esc close"))
+	return popupStyle.Render(strings.Join(lines, "\n"))
+}
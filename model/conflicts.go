@@ -0,0 +1,84 @@
+package model
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hejmsdz/bb/prs"
+)
+
+// conflictsCheck remembers whether the pull request had conflicts at its last commit.
+type conflictsCheck struct {
+	commit       string
+	hasConflicts bool
+}
+
+type msgConflictsChecked struct {
+	checks map[prs.Uid]conflictsCheck
+}
+
+// ConflictsModel finds out which pull requests have merge conflicts. It takes a request per pull request,
+// so it only checks them while a filter asks for has:conflicts, and again only after a new commit.
+type ConflictsModel struct {
+	client   prs.Client
+	checks   map[prs.Uid]conflictsCheck
+	checking map[prs.Uid]bool
+}
+
+func NewConflictsModel(client prs.Client) ConflictsModel {
+	return ConflictsModel{
+		client:   client,
+		checks:   make(map[prs.Uid]conflictsCheck),
+		checking: make(map[prs.Uid]bool),
+	}
+}
+
+// Check starts checking the pull requests which were not checked at their last commit yet.
+func (m *ConflictsModel) Check(prList []prs.PullRequest) tea.Cmd {
+	unchecked := make([]prs.PullRequest, 0)
+	for _, pr := range prList {
+		if check, ok := m.checks[pr.Uid()]; (ok && check.commit == pr.LastCommit) || m.checking[pr.Uid()] {
+			continue
+		}
+		m.checking[pr.Uid()] = true
+		unchecked = append(unchecked, pr)
+	}
+	if len(unchecked) == 0 {
+		return nil
+	}
+
+	client := m.client
+	return func() tea.Msg {
+		checks := make(map[prs.Uid]conflictsCheck)
+		for _, pr := range unchecked {
+			hasConflicts, err := client.HasConflicts(pr)
+			if err != nil {
+				continue
+			}
+			checks[pr.Uid()] = conflictsCheck{pr.LastCommit, hasConflicts}
+		}
+		return msgConflictsChecked{checks}
+	}
+}
+
+// Annotate fills in HasConflicts of the pull request, if it was checked at its last commit.
+func (m ConflictsModel) Annotate(pr prs.PullRequest) prs.PullRequest {
+	if check, ok := m.checks[pr.Uid()]; ok && check.commit == pr.LastCommit {
+		pr.HasConflicts = check.hasConflicts
+	}
+	return pr
+}
+
+func (m ConflictsModel) Update(msg tea.Msg) (ConflictsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case MsgPrsLoaded:
+		m.checking = make(map[prs.Uid]bool)
+
+	case msgConflictsChecked:
+		// The ones which failed are not checked again until the pull requests are loaded.
+		for uid, check := range msg.checks {
+			m.checks[uid] = check
+			delete(m.checking, uid)
+		}
+		return m, UpdateListView
+	}
+	return m, nil
+}
@@ -0,0 +1,346 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hejmsdz/bb/prs"
+)
+
+type editField int
+
+const (
+	editTitle editField = iota
+	editDescription
+	editTarget
+	editFieldsCount
+)
+
+// branchesShown is how many matching branches the target branch picker lists.
+const branchesShown = 5
+
+// MsgEditDescription asks for the description to be edited in $EDITOR.
+type MsgEditDescription struct {
+	Text string
+}
+
+// MsgDescriptionEdited brings the description back from $EDITOR.
+type MsgDescriptionEdited struct {
+	Text string
+	Err  error
+}
+
+type msgEditFormLoaded struct {
+	pr       prs.PullRequest
+	branches []string
+	err      error
+}
+
+type msgEditFailed struct {
+	uid prs.Uid
+	err error
+}
+
+// EditFormModel edits the title, description and target branch of a pull request.
+type EditFormModel struct {
+	client       prs.Client
+	pr           *prs.PullRequest
+	title        textinput.Model
+	description  textarea.Model
+	branchFilter textinput.Model
+	branches     []string
+	branchCursor int
+	focus        editField
+	loading      bool
+	loaded       bool
+	saving       bool
+	err          error
+}
+
+var (
+	editTitleStyle    = lipgloss.NewStyle().Bold(true)
+	editLabelStyle    = lipgloss.NewStyle().Faint(true)
+	editFocusedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Bold(true)
+	editErrorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	editSelectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Bold(true)
+)
+
+func NewEditFormModel(client prs.Client) EditFormModel {
+	description := textarea.New()
+	description.ShowLineNumbers = false
+	description.CharLimit = 0
+	branchFilter := textinput.New()
+	branchFilter.Placeholder = "filter branches"
+	return EditFormModel{
+		client:       client,
+		title:        textinput.New(),
+		description:  description,
+		branchFilter: branchFilter,
+	}
+}
+
+func (m EditFormModel) IsVisible() bool {
+	return m.pr != nil
+}
+
+// SetSize fits the description into the space left by the other fields.
+func (m *EditFormModel) SetSize(width int, height int) {
+	m.title.Width = width - 3
+	m.branchFilter.Width = width - 3
+	m.description.SetWidth(width)
+	m.description.SetHeight(height - 10 - branchesShown)
+}
+
+// Open shows the form for the pull request, loading its description and the branches of its repository.
+func (m *EditFormModel) Open(pr prs.PullRequest) tea.Cmd {
+	m.pr = &pr
+	m.title.SetValue(pr.Title)
+	m.description.SetValue(pr.Description)
+	m.branchFilter.SetValue("")
+	m.branches = []string{pr.TargetBranch}
+	m.branchCursor = 0
+	m.err = nil
+	m.saving = false
+	m.loading = true
+	m.loaded = false
+
+	client := m.client
+	return tea.Batch(m.setFocus(editTitle), func() tea.Msg {
+		fullPr, err := client.GetPullRequest(pr.Repo, pr.Id)
+		if err != nil {
+			return msgEditFormLoaded{pr: pr, err: err}
+		}
+		branches, err := client.GetBranches(pr.Repo)
+		return msgEditFormLoaded{fullPr, branches, err}
+	})
+}
+
+func (m *EditFormModel) Close() {
+	m.pr = nil
+	m.setFocus(editTitle)
+	m.title.Blur()
+}
+
+func (m *EditFormModel) setFocus(field editField) tea.Cmd {
+	m.focus = field
+	m.title.Blur()
+	m.description.Blur()
+	m.branchFilter.Blur()
+	switch field {
+	case editTitle:
+		return m.title.Focus()
+	case editDescription:
+		return m.description.Focus()
+	}
+	return m.branchFilter.Focus()
+}
+
+// matchingBranches lists the branches containing the filter, except the source branch.
+func (m EditFormModel) matchingBranches() []string {
+	filter := strings.ToLower(m.branchFilter.Value())
+	matching := make([]string, 0, len(m.branches))
+	for _, branch := range m.branches {
+		if branch != m.pr.Branch && strings.Contains(strings.ToLower(branch), filter) {
+			matching = append(matching, branch)
+		}
+	}
+	return matching
+}
+
+func (m EditFormModel) selectedBranch() string {
+	matching := m.matchingBranches()
+	if m.branchCursor >= len(matching) {
+		return ""
+	}
+	return matching[m.branchCursor]
+}
+
+// save refuses until the pull request is loaded, otherwise its description would be overwritten.
+func (m *EditFormModel) save() tea.Cmd {
+	if !m.loaded {
+		if !m.loading {
+			m.err = fmt.Errorf("could not load the pull request, reopen the form to try again")
+		}
+		return nil
+	}
+	update := prs.PullRequestUpdate{
+		Title:        strings.TrimSpace(m.title.Value()),
+		Description:  m.description.Value(),
+		TargetBranch: m.selectedBranch(),
+	}
+	if update.Title == "" {
+		m.err = fmt.Errorf("the title can't be empty")
+		return nil
+	}
+	if update.TargetBranch == "" {
+		m.err = fmt.Errorf("no branch matches %q", m.branchFilter.Value())
+		return nil
+	}
+	if update.Title == m.pr.Title && update.Description == m.pr.Description && update.TargetBranch == m.pr.TargetBranch {
+		m.Close()
+		return nil
+	}
+
+	m.saving = true
+	m.err = nil
+	pr, client := *m.pr, m.client
+	return func() tea.Msg {
+		updated, err := client.UpdatePullRequest(pr, update)
+		if err != nil {
+			return msgEditFailed{pr.Uid(), err}
+		}
+		return MsgPrUpdated{updated}
+	}
+}
+
+func (m EditFormModel) Update(msg tea.Msg) (EditFormModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case msgEditFormLoaded:
+		if m.pr == nil || m.pr.Uid() != msg.pr.Uid() {
+			return m, nil
+		}
+		m.loading = false
+		if msg.err != nil {
+			m.err = fmt.Errorf("could not load the pull request: %w", msg.err)
+			return m, nil
+		}
+		// Keep whatever was typed while loading.
+		if m.title.Value() == m.pr.Title {
+			m.title.SetValue(msg.pr.Title)
+		}
+		if m.description.Value() == m.pr.Description {
+			m.description.SetValue(msg.pr.Description)
+		}
+		m.pr = &msg.pr
+		m.loaded = true
+		m.branches = msg.branches
+		for i, branch := range m.matchingBranches() {
+			if branch == msg.pr.TargetBranch {
+				m.branchCursor = i
+			}
+		}
+
+	case MsgDescriptionEdited:
+		if m.pr == nil {
+			return m, nil
+		}
+		if msg.Err != nil {
+			m.err = msg.Err
+			return m, nil
+		}
+		m.description.SetValue(strings.TrimRight(msg.Text, "\n"))
+
+	case MsgPrUpdated:
+		if m.saving && m.pr.Uid() == msg.Pr.Uid() {
+			m.Close()
+		}
+
+	case msgEditFailed:
+		if m.pr != nil && m.pr.Uid() == msg.uid {
+			m.saving = false
+			m.err = msg.err
+		}
+
+	case tea.KeyMsg:
+		if m.pr == nil || m.saving {
+			return m, nil
+		}
+		switch msg.String() {
+		case "esc":
+			m.Close()
+			return m, nil
+		case "tab":
+			return m, m.setFocus((m.focus + 1) % editFieldsCount)
+		case "shift+tab":
+			return m, m.setFocus((m.focus + editFieldsCount - 1) % editFieldsCount)
+		case "ctrl+s":
+			return m, m.save()
+		}
+
+		var cmd tea.Cmd
+		switch m.focus {
+		case editTitle:
+			if msg.String() == "enter" {
+				return m, m.setFocus(editDescription)
+			}
+			m.title, cmd = m.title.Update(msg)
+
+		case editDescription:
+			if msg.String() == "ctrl+o" {
+				text := m.description.Value()
+				return m, func() tea.Msg { return MsgEditDescription{text} }
+			}
+			m.description, cmd = m.description.Update(msg)
+
+		case editTarget:
+			switch msg.String() {
+			case "up", "ctrl+p":
+				if m.branchCursor > 0 {
+					m.branchCursor--
+				}
+				return m, nil
+			case "down", "ctrl+n":
+				if m.branchCursor < len(m.matchingBranches())-1 {
+					m.branchCursor++
+				}
+				return m, nil
+			case "enter":
+				return m, m.save()
+			}
+			m.branchFilter, cmd = m.branchFilter.Update(msg)
+			m.branchCursor = 0
+		}
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m EditFormModel) label(field editField, text string) string {
+	if m.focus == field {
+		return editFocusedStyle.Render(text)
+	}
+	return editLabelStyle.Render(text)
+}
+
+func (m EditFormModel) View() string {
+	if m.pr == nil {
+		return ""
+	}
+	lines := []string{
+		editTitleStyle.Render(fmt.Sprintf("Edit %s#%s", m.pr.Repo, m.pr.Id)),
+		m.label(editTitle, "Title"),
+		m.title.View(),
+		m.label(editDescription, "Description · ctrl+o opens $EDITOR"),
+		m.description.View(),
+		m.label(editTarget, "Target branch"),
+		m.branchFilter.View(),
+	}
+
+	matching := m.matchingBranches()
+	first := 0
+	if m.branchCursor >= branchesShown {
+		first = m.branchCursor - branchesShown + 1
+	}
+	for i := first; i < len(matching) && i < first+branchesShown; i++ {
+		if i == m.branchCursor {
+			lines = append(lines, editSelectedStyle.Render("> "+matching[i]))
+		} else {
+			lines = append(lines, "  "+matching[i])
+		}
+	}
+
+	lines = append(lines, "", editLabelStyle.Render("tab next field • ctrl+s save • esc cancel"))
+	switch {
+	case m.loading:
+		lines = append(lines, editLabelStyle.Render("Loading branches…"))
+	case m.saving:
+		lines = append(lines, editLabelStyle.Render("Saving…"))
+	case m.err != nil:
+		lines = append(lines, editErrorStyle.Render(m.err.Error()))
+	}
+	return strings.Join(lines, "\n")
+}
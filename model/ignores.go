@@ -42,3 +42,12 @@ func (m *IgnoresModel) ToggleShowIgnored() tea.Cmd {
 	m.ShowIgnored = !m.ShowIgnored
 	return tea.Batch(UpdateListView)
 }
+
+// Prune forgets ignored pull requests which are no longer open.
+func (m IgnoresModel) Prune(open map[prs.Uid]bool) {
+	for uid := range m.IgnoredPrs {
+		if !open[uid] {
+			delete(m.IgnoredPrs, uid)
+		}
+	}
+}
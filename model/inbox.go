@@ -0,0 +1,85 @@
+package model
+
+import (
+	"github.com/hejmsdz/bb/prs"
+)
+
+type InboxState int
+
+// Inbox states are ordered from the most to the least actionable.
+const (
+	AwaitingMyReview InboxState = iota
+	NewCommitsSinceMyReview
+	MyPrChangesRequested
+	MyPrReadyToMerge
+	WaitingOnOthers
+)
+
+var inboxStates = []InboxState{AwaitingMyReview, NewCommitsSinceMyReview, MyPrChangesRequested, MyPrReadyToMerge, WaitingOnOthers}
+
+func (s InboxState) String() string {
+	switch s {
+	case AwaitingMyReview:
+		return "Awaiting my review"
+	case NewCommitsSinceMyReview:
+		return "New commits since my review"
+	case MyPrChangesRequested:
+		return "Changes requested"
+	case MyPrReadyToMerge:
+		return "Ready to merge"
+	}
+	return "Waiting on others"
+}
+
+// Slug identifies the state in filter queries, e.g. state:awaiting-review.
+func (s InboxState) Slug() string {
+	switch s {
+	case AwaitingMyReview:
+		return "awaiting-review"
+	case NewCommitsSinceMyReview:
+		return "new-commits"
+	case MyPrChangesRequested:
+		return "changes-requested"
+	case MyPrReadyToMerge:
+		return "ready-to-merge"
+	}
+	return "waiting"
+}
+
+func (s InboxState) Badge() string {
+	switch s {
+	case AwaitingMyReview:
+		return "👀"
+	case NewCommitsSinceMyReview:
+		return "🆕"
+	case MyPrChangesRequested:
+		return "🛠"
+	case MyPrReadyToMerge:
+		return "🚀"
+	}
+	return "⏳"
+}
+
+func Classify(pr prs.PullRequest, changes []string) InboxState {
+	if pr.IsMine {
+		if pr.RequestedChangesCount > 0 {
+			return MyPrChangesRequested
+		}
+		if pr.ApprovedCount > 0 {
+			return MyPrReadyToMerge
+		}
+		return WaitingOnOthers
+	}
+
+	if pr.AmIParticipating && !pr.IsDraft {
+		if pr.MyReview == prs.NoReview {
+			return AwaitingMyReview
+		}
+		for _, change := range changes {
+			if change == changeCommitted {
+				return NewCommitsSinceMyReview
+			}
+		}
+	}
+	return WaitingOnOthers
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/hejmsdz/bb/prs"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name    string
+		pr      prs.PullRequest
+		changes []string
+		want    InboxState
+	}{
+		{"not reviewed yet", prs.PullRequest{AmIParticipating: true}, nil, AwaitingMyReview},
+		{"draft", prs.PullRequest{AmIParticipating: true, IsDraft: true}, nil, WaitingOnOthers},
+		{"approved, nothing new", prs.PullRequest{AmIParticipating: true, MyReview: prs.Approved}, nil, WaitingOnOthers},
+		{"approved, new commits", prs.PullRequest{AmIParticipating: true, MyReview: prs.Approved}, []string{changeCommitted}, NewCommitsSinceMyReview},
+		{"requested changes, new commits", prs.PullRequest{AmIParticipating: true, MyReview: prs.RequestedChanges}, []string{changeCommitted}, NewCommitsSinceMyReview},
+		{"mine with changes requested", prs.PullRequest{IsMine: true, ApprovedCount: 1, RequestedChangesCount: 1}, nil, MyPrChangesRequested},
+		{"mine approved", prs.PullRequest{IsMine: true, ApprovedCount: 1}, nil, MyPrReadyToMerge},
+		{"mine without reviews", prs.PullRequest{IsMine: true}, nil, WaitingOnOthers},
+	}
+	for _, test := range tests {
+		if got := Classify(test.pr, test.changes); got != test.want {
+			t.Errorf("%s: expected %s, got %s", test.name, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type JobStatus int
+
+const (
+	JobQueued JobStatus = iota
+	JobRunning
+	JobSucceeded
+	JobFailed
+)
+
+func (s JobStatus) String() string {
+	switch s {
+	case JobQueued:
+		return "queued"
+	case JobRunning:
+		return "running"
+	case JobSucceeded:
+		return "done"
+	}
+	return "failed"
+}
+
+type Job struct {
+	Id         int
+	Subject    string
+	Name       string
+	Status     JobStatus
+	Output     []string
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+func (j Job) Duration() time.Duration {
+	switch j.Status {
+	case JobQueued:
+		return 0
+	case JobRunning:
+		return time.Since(j.StartedAt).Round(time.Second)
+	}
+	return j.FinishedAt.Sub(j.StartedAt).Round(100 * time.Millisecond)
+}
+
+type MsgJobQueued struct {
+	Job Job
+}
+
+type MsgJobStarted struct {
+	Id        int
+	StartedAt time.Time
+}
+
+type MsgJobOutput struct {
+	Id   int
+	Line string
+}
+
+type MsgJobFinished struct {
+	Id         int
+	Ok         bool
+	FinishedAt time.Time
+}
+
+// msgJobsTick redraws the elapsed time of the running jobs.
+type msgJobsTick struct{}
+
+const maxJobs = 50
+
+type JobsModel struct {
+	jobs    []Job
+	visible bool
+	ticking bool
+}
+
+var (
+	jobTitleStyle   = lipgloss.NewStyle().Bold(true)
+	jobOutputStyle  = lipgloss.NewStyle().Faint(true).PaddingLeft(2)
+	jobStatusStyles = map[JobStatus]lipgloss.Style{
+		JobQueued:    lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
+		JobRunning:   lipgloss.NewStyle().Foreground(lipgloss.Color("6")),
+		JobSucceeded: lipgloss.NewStyle().Foreground(lipgloss.Color("2")),
+		JobFailed:    lipgloss.NewStyle().Foreground(lipgloss.Color("1")),
+	}
+)
+
+func NewJobsModel() JobsModel {
+	return JobsModel{
+		jobs: make([]Job, 0),
+	}
+}
+
+func (m JobsModel) IsVisible() bool {
+	return m.visible
+}
+
+func (m *JobsModel) ToggleVisible() {
+	m.visible = !m.visible
+}
+
+func (m JobsModel) RunningCount() int {
+	count := 0
+	for _, job := range m.jobs {
+		if job.Status == JobQueued || job.Status == JobRunning {
+			count++
+		}
+	}
+	return count
+}
+
+// tick schedules the next redraw while any job is running, unless one is scheduled already.
+func (m *JobsModel) tick() tea.Cmd {
+	if m.ticking || m.RunningCount() == 0 {
+		return nil
+	}
+	m.ticking = true
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return msgJobsTick{}
+	})
+}
+
+func (m JobsModel) find(id int) int {
+	for i, job := range m.jobs {
+		if job.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m JobsModel) Update(msg tea.Msg) (JobsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case MsgJobQueued:
+		m.jobs = append(m.jobs, msg.Job)
+		if len(m.jobs) > maxJobs {
+			m.jobs = m.jobs[len(m.jobs)-maxJobs:]
+		}
+
+	case MsgJobStarted:
+		if i := m.find(msg.Id); i >= 0 {
+			m.jobs[i].Status = JobRunning
+			m.jobs[i].StartedAt = msg.StartedAt
+		}
+		return m, m.tick()
+
+	case msgJobsTick:
+		m.ticking = false
+		return m, m.tick()
+
+	case MsgJobOutput:
+		if i := m.find(msg.Id); i >= 0 {
+			m.jobs[i].Output = append(m.jobs[i].Output, msg.Line)
+		}
+
+	case MsgJobFinished:
+		if i := m.find(msg.Id); i >= 0 {
+			m.jobs[i].Status = JobFailed
+			if msg.Ok {
+				m.jobs[i].Status = JobSucceeded
+			}
+			m.jobs[i].FinishedAt = msg.FinishedAt
+		}
+	}
+	return m, nil
+}
+
+// View renders the most recent jobs first, showing only the tail of their output to fit in height.
+func (m JobsModel) View(height int) string {
+	lines := []string{jobTitleStyle.Render(fmt.Sprintf("Jobs (%d running)", m.RunningCount())), ""}
+	if len(m.jobs) == 0 {
+		lines = append(lines, "No jobs yet.")
+	}
+
+	const outputLines = 5
+	for i := len(m.jobs) - 1; i >= 0 && len(lines) < height; i-- {
+		job := m.jobs[i]
+		status := jobStatusStyles[job.Status].Render(fmt.Sprintf("[%s]", job.Status))
+		lines = append(lines, fmt.Sprintf("%s %s · %s · %s", status, job.Name, job.Subject, job.Duration()))
+
+		output := job.Output
+		if len(output) > outputLines {
+			output = output[len(output)-outputLines:]
+		}
+		for _, line := range output {
+			lines = append(lines, jobOutputStyle.Render(line))
+		}
+	}
+
+	if len(lines) > height && height > 0 {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -12,7 +12,8 @@ type MsgShowToast struct {
 	Style lipgloss.Style
 }
 
-func NewItemDelegate() list.DefaultDelegate {
+// NewItemDelegate creates the list delegate, extraBindings are listed in the full help.
+func NewItemDelegate(extraBindings []key.Binding) list.DefaultDelegate {
 	d := list.NewDefaultDelegate()
 
 	d.UpdateFunc = func(msg tea.Msg, m *list.Model) tea.Cmd {
@@ -20,7 +21,7 @@ func NewItemDelegate() list.DefaultDelegate {
 		case MsgPrsLoading:
 			return m.StartSpinner()
 
-		case MsgPrsLoaded:
+		case MsgPrsLoaded, MsgPrsLoadFailed:
 			m.StopSpinner()
 			return nil
 
@@ -32,7 +33,7 @@ func NewItemDelegate() list.DefaultDelegate {
 	}
 
 	d.FullHelpFunc = func() [][]key.Binding {
-		return [][]key.Binding{{
+		bindings := [][]key.Binding{{
 			key.NewBinding(
 				key.WithKeys("enter"),
 				key.WithHelp("enter", "open in web browser"),
@@ -41,10 +42,26 @@ func NewItemDelegate() list.DefaultDelegate {
 				key.WithKeys("d"),
 				key.WithHelp("d", "dismiss bell"),
 			),
+			key.NewBinding(
+				key.WithKeys("w"),
+				key.WithHelp("w", "what changed"),
+			),
 			key.NewBinding(
 				key.WithKeys("i"),
 				key.WithHelp("i", "ignore until next update"),
 			),
+			key.NewBinding(
+				key.WithKeys("W"),
+				key.WithHelp("W", "watch"),
+			),
+			key.NewBinding(
+				key.WithKeys("R"),
+				key.WithHelp("R", "reviewers"),
+			),
+			key.NewBinding(
+				key.WithKeys("E"),
+				key.WithHelp("E", "edit title, description and target"),
+			),
 			key.NewBinding(
 				key.WithKeys("u"),
 				key.WithHelp("u", "copy url"),
@@ -59,7 +76,27 @@ func NewItemDelegate() list.DefaultDelegate {
 			),
 			key.NewBinding(
 				key.WithKeys("P"),
-				key.WithHelp("P", "pull target branch"),
+				key.WithHelp("P", "update from target branch"),
+			),
+			key.NewBinding(
+				key.WithKeys("e"),
+				key.WithHelp("e", "open in editor"),
+			),
+			key.NewBinding(
+				key.WithKeys("t"),
+				key.WithHelp("t", "open shell"),
+			),
+			key.NewBinding(
+				key.WithKeys("X"),
+				key.WithHelp("X", "clean up worktrees"),
+			),
+			key.NewBinding(
+				key.WithKeys("J"),
+				key.WithHelp("J", "show jobs"),
+			),
+			key.NewBinding(
+				key.WithKeys("A"),
+				key.WithHelp("A", "show activity"),
 			),
 		}, {
 			key.NewBinding(
@@ -74,7 +111,43 @@ func NewItemDelegate() list.DefaultDelegate {
 				key.WithKeys("m"),
 				key.WithHelp("m", "show mine only"),
 			),
+			key.NewBinding(
+				key.WithKeys("a"),
+				key.WithHelp("a", "show all"),
+			),
+			key.NewBinding(
+				key.WithKeys("D"),
+				key.WithHelp("D", "show drafts"),
+			),
+			key.NewBinding(
+				key.WithKeys("s"),
+				key.WithHelp("s", "my stale approvals"),
+			),
+			key.NewBinding(
+				key.WithKeys("f"),
+				key.WithHelp("f", "filter by query"),
+			),
+			key.NewBinding(
+				key.WithKeys("F"),
+				key.WithHelp("F", "clear query"),
+			),
+			key.NewBinding(
+				key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+				key.WithHelp("1-9", "switch view"),
+			),
+			key.NewBinding(
+				key.WithKeys("o"),
+				key.WithHelp("o", "change sort order"),
+			),
+			key.NewBinding(
+				key.WithKeys("O"),
+				key.WithHelp("O", "change grouping"),
+			),
 		}}
+		if len(extraBindings) > 0 {
+			bindings = append(bindings, extraBindings)
+		}
+		return bindings
 	}
 
 	return d
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hejmsdz/bb/prs"
+)
+
+type LocalBranchStatus struct {
+	Exists     bool
+	CheckedOut bool
+	// Unpushed is the number of local commits missing from the pull request.
+	Unpushed int
+	// Behind is the number of pull request commits missing locally,
+	// or -1 if the last commit has not been fetched yet.
+	Behind int
+}
+
+func (s LocalBranchStatus) String() string {
+	if !s.Exists {
+		return ""
+	}
+	parts := []string{"⎇ local"}
+	if s.CheckedOut {
+		parts[0] = "⎇ checked out"
+	}
+	if s.Unpushed > 0 {
+		parts = append(parts, fmt.Sprintf("↑%d", s.Unpushed))
+	}
+	if s.Behind > 0 {
+		parts = append(parts, fmt.Sprintf("↓%d", s.Behind))
+	} else if s.Behind < 0 {
+		parts = append(parts, "↓ not fetched")
+	}
+	return strings.Join(parts, " ")
+}
+
+type MsgLocalStatusLoaded struct {
+	Statuses map[prs.Uid]LocalBranchStatus
+}
+
+type LocalStatusModel struct {
+	statuses map[prs.Uid]LocalBranchStatus
+}
+
+func NewLocalStatusModel() LocalStatusModel {
+	return LocalStatusModel{
+		statuses: make(map[prs.Uid]LocalBranchStatus),
+	}
+}
+
+func (m LocalStatusModel) Get(pr prs.PullRequest) LocalBranchStatus {
+	return m.statuses[pr.Uid()]
+}
+
+func (m LocalStatusModel) Update(msg tea.Msg) (LocalStatusModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case MsgLocalStatusLoaded:
+		m.statuses = msg.Statuses
+		return m, UpdateListView
+	}
+	return m, nil
+}
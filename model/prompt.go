@@ -0,0 +1,93 @@
+package model
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type Choice struct {
+	Key   string
+	Label string
+	Cmd   tea.Cmd
+}
+
+// MsgPrompt asks the user to pick one of the choices, esc picks none.
+type MsgPrompt struct {
+	Text     string
+	Choices  []Choice
+	OnCancel tea.Cmd
+}
+
+type PromptModel struct {
+	pending *MsgPrompt
+}
+
+var (
+	promptStyle    = lipgloss.NewStyle().Bold(true)
+	promptKeyStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+)
+
+func NewPromptModel() PromptModel {
+	return PromptModel{}
+}
+
+func Confirm(text string, onYes tea.Cmd, onNo tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		return MsgPrompt{
+			Text: text,
+			Choices: []Choice{
+				{Key: "y", Label: "yes", Cmd: onYes},
+				{Key: "n", Label: "no", Cmd: onNo},
+			},
+			OnCancel: onNo,
+		}
+	}
+}
+
+func (m PromptModel) IsActive() bool {
+	return m.pending != nil
+}
+
+func (m PromptModel) Update(msg tea.Msg) (PromptModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case MsgPrompt:
+		// A new prompt cancels the pending one, which may have a job waiting for the answer.
+		var cancelCmd tea.Cmd
+		if m.pending != nil {
+			cancelCmd = m.pending.OnCancel
+		}
+		m.pending = &msg
+		return m, cancelCmd
+
+	case tea.KeyMsg:
+		if m.pending == nil {
+			return m, nil
+		}
+		prompt := m.pending
+		if msg.String() == "esc" || msg.String() == "ctrl+c" {
+			m.pending = nil
+			return m, prompt.OnCancel
+		}
+		for _, choice := range prompt.Choices {
+			if msg.String() == choice.Key {
+				m.pending = nil
+				return m, choice.Cmd
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m PromptModel) View() string {
+	if m.pending == nil {
+		return ""
+	}
+	choices := make([]string, 0, len(m.pending.Choices)+1)
+	for _, choice := range m.pending.Choices {
+		choices = append(choices, promptKeyStyle.Render("["+choice.Key+"]")+" "+choice.Label)
+	}
+	choices = append(choices, promptKeyStyle.Render("[esc]")+" cancel")
+	return promptStyle.Render(m.pending.Text) + " " + strings.Join(choices, " / ")
+}
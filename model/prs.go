@@ -1,6 +1,8 @@
 package model
 
 import (
+	"errors"
+	"sort"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -8,9 +10,13 @@ import (
 )
 
 type PrsModel struct {
-	client    prs.Client
-	Prs       []prs.PullRequest
-	updatedOn time.Time
+	client     prs.Client
+	Prs        []prs.PullRequest
+	UpdatedOn  time.Time
+	err        error
+	closed     []prs.PullRequest
+	failed     prs.RepositoryErrors
+	isRelevant func(prs.PullRequest) bool
 }
 
 func NewPrsModel(client prs.Client) PrsModel {
@@ -20,6 +26,11 @@ func NewPrsModel(client prs.Client) PrsModel {
 	}
 }
 
+// SetRelevance limits the pull requests which are looked up when they disappear from the list.
+func (m *PrsModel) SetRelevance(isRelevant func(prs.PullRequest) bool) {
+	m.isRelevant = isRelevant
+}
+
 func (m PrsModel) Init() tea.Cmd {
 	return m.StartLoadingPrs
 }
@@ -29,15 +40,45 @@ type MsgPrsLoading struct{}
 type MsgPrsLoaded struct {
 	prs       []prs.PullRequest
 	updatedOn time.Time
+	failed    prs.RepositoryErrors
+}
+
+type MsgPrsLoadFailed struct {
+	Err error
+}
+
+// MsgPrsClosed reports the pull requests which disappeared from the list, in their final state.
+// At is the time of the load they disappeared in.
+type MsgPrsClosed struct {
+	Prs []prs.PullRequest
+	At  time.Time
 }
 
 func (m PrsModel) StartLoadingPrs() tea.Msg {
 	return MsgPrsLoading{}
 }
 
+// loadPrs keeps the last known pull requests of the repositories which could not be loaded,
+// so that they don't look closed.
 func (m PrsModel) loadPrs() tea.Msg {
-	prs := m.client.GetAllPullRequests()
-	return MsgPrsLoaded{prs, time.Now()}
+	prList, err := m.client.GetAllPullRequests()
+	var failed prs.RepositoryErrors
+	if err != nil && (prList == nil || !errors.As(err, &failed)) {
+		return MsgPrsLoadFailed{err}
+	}
+	if len(failed) == 0 {
+		return MsgPrsLoaded{prList, time.Now(), nil}
+	}
+
+	for _, pr := range m.Prs {
+		if _, ok := failed[pr.Repo]; ok {
+			prList = append(prList, pr)
+		}
+	}
+	sort.SliceStable(prList, func(i, j int) bool {
+		return prList[i].UpdatedOn.After(prList[j].UpdatedOn)
+	})
+	return MsgPrsLoaded{prList, time.Now(), failed}
 }
 
 func (m PrsModel) Update(msg tea.Msg) (PrsModel, tea.Cmd) {
@@ -46,10 +87,112 @@ func (m PrsModel) Update(msg tea.Msg) (PrsModel, tea.Cmd) {
 		return m, m.loadPrs
 
 	case MsgPrsLoaded:
+		var loadClosedCmd tea.Cmd
+		if m.IsLoaded() {
+			loadClosedCmd = m.loadClosed(m.disappeared(msg.prs, msg.failed), msg.updatedOn)
+		}
 		m.Prs = msg.prs
-		m.updatedOn = msg.updatedOn
+		m.UpdatedOn = msg.updatedOn
+		m.err = nil
+		m.closed = nil
+		m.failed = msg.failed
+		return m, tea.Batch(UpdateListView, loadClosedCmd)
+
+	case MsgPrsClosed:
+		m.closed = msg.Prs
+		return m, UpdateListView
+
+	case MsgPrsLoadFailed:
+		m.err = msg.Err
+		return m, UpdateListView
+
+	case MsgPrUpdated:
+		prList := make([]prs.PullRequest, len(m.Prs))
+		for i, pr := range m.Prs {
+			prList[i] = pr
+			if pr.Uid() == msg.Pr.Uid() {
+				prList[i] = msg.Pr
+			}
+		}
+		m.Prs = prList
 		return m, UpdateListView
 	}
 
 	return m, nil
 }
+
+// disappeared skips the repositories which failed to load, their pull requests may well be open.
+func (m PrsModel) disappeared(newPrs []prs.PullRequest, failed prs.RepositoryErrors) []prs.PullRequest {
+	isOpen := make(map[prs.Uid]bool)
+	for _, pr := range newPrs {
+		isOpen[pr.Uid()] = true
+	}
+	disappeared := make([]prs.PullRequest, 0)
+	for _, pr := range m.Prs {
+		if _, isFailed := failed[pr.Repo]; isFailed {
+			continue
+		}
+		if !isOpen[pr.Uid()] && (m.isRelevant == nil || m.isRelevant(pr)) {
+			disappeared = append(disappeared, pr)
+		}
+	}
+	return disappeared
+}
+
+// loadClosed finds out how the pull requests were closed, skipping the ones which are
+// still open, but no longer match, e.g. because I was removed from the reviewers.
+func (m PrsModel) loadClosed(disappeared []prs.PullRequest, at time.Time) tea.Cmd {
+	if len(disappeared) == 0 {
+		return nil
+	}
+	client := m.client
+	return func() tea.Msg {
+		closed := make([]prs.PullRequest, 0)
+		for _, pr := range disappeared {
+			finalPr, err := client.GetPullRequest(pr.Repo, pr.Id)
+			if err != nil || finalPr.State == prs.StateOpen {
+				continue
+			}
+			closed = append(closed, finalPr)
+		}
+		if len(closed) == 0 {
+			return nil
+		}
+		return MsgPrsClosed{closed, at}
+	}
+}
+
+// Closed lists the pull requests closed since the previous load, shown until the next one.
+func (m PrsModel) Closed() []prs.PullRequest {
+	return m.closed
+}
+
+func (m *PrsModel) DismissClosed(pr prs.PullRequest) tea.Cmd {
+	closed := make([]prs.PullRequest, 0, len(m.closed))
+	for _, closedPr := range m.closed {
+		if closedPr.Uid() != pr.Uid() {
+			closed = append(closed, closedPr)
+		}
+	}
+	m.closed = closed
+	return UpdateListView
+}
+
+// FailedRepos names the repositories which could not be loaded the last time, their pull requests are from before.
+func (m PrsModel) FailedRepos() prs.RepositoryErrors {
+	return m.failed
+}
+
+func (m PrsModel) IsLoaded() bool {
+	return !m.UpdatedOn.IsZero()
+}
+
+// IsOffline tells whether the last attempt to load pull requests failed,
+// the list then shows the last known snapshot.
+func (m PrsModel) IsOffline() bool {
+	return m.err != nil
+}
+
+func (m PrsModel) Err() error {
+	return m.err
+}
@@ -0,0 +1,334 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hejmsdz/bb/prs"
+)
+
+type Predicate func(pr prs.PullRequest, changes []string) bool
+
+type SavedFilter struct {
+	Name  string
+	Key   string
+	Query string
+}
+
+type QueryModel struct {
+	Query      string
+	predicates []Predicate
+	editing    bool
+	input      textinput.Model
+	err        error
+}
+
+func NewQueryModel() QueryModel {
+	input := textinput.New()
+	input.Prompt = "Filter: "
+	input.Placeholder = "repo:acme/api author:me -approved:me updated:<2d"
+	return QueryModel{
+		input: input,
+	}
+}
+
+func (m QueryModel) IsEditing() bool {
+	return m.editing
+}
+
+func (m *QueryModel) StartEditing() tea.Cmd {
+	m.editing = true
+	m.err = nil
+	m.input.SetValue(m.Query)
+	m.input.CursorEnd()
+	return m.input.Focus()
+}
+
+// SetQuery applies a new query, leaving the current one in place if it does not parse.
+// The query is compiled once here rather than for every pull request.
+func (m *QueryModel) SetQuery(query string) (tea.Cmd, error) {
+	predicates, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	m.Query = strings.TrimSpace(query)
+	m.predicates = predicates
+	return UpdateListView, nil
+}
+
+// NeedsConflicts tells whether the query filters by has:conflicts, which has to be checked for every pull request.
+func (m QueryModel) NeedsConflicts() bool {
+	return usesConflicts(m.Query)
+}
+
+func (m QueryModel) IsHidden(pr prs.PullRequest, changes []string) bool {
+	for _, predicate := range m.predicates {
+		if !predicate(pr, changes) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m QueryModel) Update(msg tea.Msg) (QueryModel, tea.Cmd) {
+	if !m.editing {
+		return m, nil
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc":
+			m.editing = false
+			m.input.Blur()
+			return m, nil
+
+		case "enter":
+			cmd, err := m.SetQuery(m.input.Value())
+			m.err = err
+			if err != nil {
+				return m, nil
+			}
+			m.editing = false
+			m.input.Blur()
+			return m, cmd
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m QueryModel) View() string {
+	if m.err != nil {
+		return m.input.View() + "\n" + m.err.Error()
+	}
+	return m.input.View()
+}
+
+func ParseQuery(query string) ([]Predicate, error) {
+	predicates := make([]Predicate, 0)
+	for _, token := range tokenize(query) {
+		negated := false
+		if strings.HasPrefix(token, "-") && len(token) > 1 {
+			negated = true
+			token = token[1:]
+		}
+
+		predicate, err := parseTerm(token)
+		if err != nil {
+			return nil, err
+		}
+		if negated {
+			positive := predicate
+			predicate = func(pr prs.PullRequest, changes []string) bool {
+				return !positive(pr, changes)
+			}
+		}
+		predicates = append(predicates, predicate)
+	}
+	return predicates, nil
+}
+
+func usesConflicts(query string) bool {
+	for _, token := range tokenize(query) {
+		if strings.TrimPrefix(token, "-") == "has:conflicts" {
+			return true
+		}
+	}
+	return false
+}
+
+func tokenize(query string) []string {
+	tokens := make([]string, 0)
+	var current strings.Builder
+	inQuotes := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case unicode.IsSpace(r) && !inQuotes:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+func parseTerm(term string) (Predicate, error) {
+	parts := strings.SplitN(term, ":", 2)
+	if len(parts) == 1 {
+		text := strings.ToLower(term)
+		return func(pr prs.PullRequest, changes []string) bool {
+			return strings.Contains(strings.ToLower(pr.Title), text)
+		}, nil
+	}
+	field, value := parts[0], parts[1]
+	if value == "" {
+		return nil, fmt.Errorf("missing value for %s:", field)
+	}
+
+	switch field {
+	case "repo":
+		return func(pr prs.PullRequest, changes []string) bool {
+			return matchPattern(value, pr.Repo) || matchPattern(value, path.Base(pr.Repo))
+		}, nil
+
+	case "branch":
+		return func(pr prs.PullRequest, changes []string) bool {
+			return matchPattern(value, pr.Branch)
+		}, nil
+
+	case "target":
+		return func(pr prs.PullRequest, changes []string) bool {
+			return matchPattern(value, pr.TargetBranch)
+		}, nil
+
+	case "author":
+		if value == "me" {
+			return func(pr prs.PullRequest, changes []string) bool {
+				return pr.IsMine
+			}, nil
+		}
+		author := strings.ToLower(value)
+		return func(pr prs.PullRequest, changes []string) bool {
+			return strings.Contains(strings.ToLower(pr.Author), author)
+		}, nil
+
+	case "reviewer":
+		if value != "me" {
+			return nil, fmt.Errorf("reviewer: only supports \"me\"")
+		}
+		return func(pr prs.PullRequest, changes []string) bool {
+			return pr.AmIParticipating
+		}, nil
+
+	case "approved":
+		if value == "me" {
+			return func(pr prs.PullRequest, changes []string) bool {
+				return pr.MyReview == prs.Approved
+			}, nil
+		}
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("approved: expects \"me\" or a number")
+		}
+		return func(pr prs.PullRequest, changes []string) bool {
+			return pr.ApprovedCount >= count
+		}, nil
+
+	case "updated", "created":
+		return parseAgeTerm(field, value)
+
+	case "has":
+		switch value {
+		case "changes":
+			return func(pr prs.PullRequest, changes []string) bool {
+				return len(changes) > 0
+			}, nil
+		case "comments":
+			return func(pr prs.PullRequest, changes []string) bool {
+				return pr.CommentsCount > 0
+			}, nil
+		case "approvals":
+			return func(pr prs.PullRequest, changes []string) bool {
+				return pr.ApprovedCount > 0
+			}, nil
+		case "conflicts":
+			return func(pr prs.PullRequest, changes []string) bool {
+				return pr.HasConflicts
+			}, nil
+		}
+		return nil, fmt.Errorf("unknown value has:%s", value)
+
+	case "is":
+		switch value {
+		case "mine":
+			return func(pr prs.PullRequest, changes []string) bool {
+				return pr.IsMine
+			}, nil
+		case "draft":
+			return func(pr prs.PullRequest, changes []string) bool {
+				return pr.IsDraft
+			}, nil
+		}
+		return nil, fmt.Errorf("unknown value is:%s", value)
+
+	case "state":
+		for _, state := range inboxStates {
+			if state.Slug() == value {
+				expected := state
+				return func(pr prs.PullRequest, changes []string) bool {
+					return Classify(pr, changes) == expected
+				}, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown value state:%s", value)
+	}
+
+	return nil, fmt.Errorf("unknown field %s:", field)
+}
+
+func matchPattern(pattern string, value string) bool {
+	if matched, err := path.Match(pattern, value); err == nil && matched {
+		return true
+	}
+	return strings.EqualFold(pattern, value)
+}
+
+// parseAgeTerm handles terms like updated:<2d (less than 2 days ago) and created:>1w.
+func parseAgeTerm(field string, value string) (Predicate, error) {
+	newerThan := true
+	switch value[0] {
+	case '<':
+		value = value[1:]
+	case '>':
+		newerThan = false
+		value = value[1:]
+	}
+
+	age, err := parseAge(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", field, err)
+	}
+
+	return func(pr prs.PullRequest, changes []string) bool {
+		t := pr.UpdatedOn
+		if field == "created" {
+			t = pr.CreatedOn
+		}
+		isNewer := time.Since(t) < age
+		return isNewer == newerThan
+	}, nil
+}
+
+func parseAge(value string) (time.Duration, error) {
+	units := map[byte]time.Duration{
+		'm': time.Minute,
+		'h': time.Hour,
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+	}
+	if len(value) < 2 {
+		return 0, fmt.Errorf("invalid age %q, expected e.g. 30m, 12h, 2d or 1w", value)
+	}
+	unit, ok := units[value[len(value)-1]]
+	count, err := strconv.Atoi(value[:len(value)-1])
+	if !ok || err != nil {
+		return 0, fmt.Errorf("invalid age %q, expected e.g. 30m, 12h, 2d or 1w", value)
+	}
+	return time.Duration(count) * unit, nil
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hejmsdz/bb/prs"
+)
+
+func matches(t *testing.T, query string, pr prs.PullRequest, changes []string) bool {
+	t.Helper()
+	predicates, err := ParseQuery(query)
+	if err != nil {
+		t.Fatalf("ParseQuery(%q): %v", query, err)
+	}
+	for _, predicate := range predicates {
+		if !predicate(pr, changes) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestParseQuery(t *testing.T) {
+	pr := prs.PullRequest{
+		Repo:             "acme/api",
+		Title:            "Add login endpoint",
+		Author:           "Alice Smith",
+		Branch:           "feature/login",
+		TargetBranch:     "release/1.2",
+		AmIParticipating: true,
+		MyReview:         prs.Approved,
+		ApprovedCount:    2,
+		UpdatedOn:        time.Now().Add(-30 * time.Hour),
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"login", true},
+		{"logout", false},
+		{"repo:acme/api", true},
+		{"repo:api", true},
+		{"repo:acme/*", true},
+		{"repo:acme/web", false},
+		{`author:"alice smith"`, true},
+		{"author:me", false},
+		{"-author:me", true},
+		{"approved:me", true},
+		{"-approved:me", false},
+		{"approved:3", false},
+		{"reviewer:me", true},
+		{"updated:<2d", true},
+		{"updated:<1d", false},
+		{"updated:>1d", true},
+		{"target:release/*", true},
+		{"branch:feature/*", true},
+		{"has:changes", false},
+		{"has:conflicts", false},
+		{"is:draft", false},
+		{"-is:draft", true},
+		{"state:waiting", true},
+		{"repo:acme/api -approved:me", false},
+	}
+	for _, test := range tests {
+		if got := matches(t, test.query, pr, nil); got != test.want {
+			t.Errorf("%q: expected %v, got %v", test.query, test.want, got)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	for _, query := range []string{"color:red", "repo:", "updated:<2y", "has:merges", "reviewer:bob", "state:unknown"} {
+		if _, err := ParseQuery(query); err == nil {
+			t.Errorf("%q: expected an error", query)
+		}
+	}
+}
+
+func TestNeedsConflicts(t *testing.T) {
+	var m QueryModel
+	if _, err := m.SetQuery("repo:acme/api -has:conflicts"); err != nil {
+		t.Fatal(err)
+	}
+	if !m.NeedsConflicts() {
+		t.Error("expected the query to need conflicts checked")
+	}
+	if m.IsHidden(prs.PullRequest{Repo: "acme/api"}, nil) {
+		t.Error("expected a pull request without conflicts to be shown")
+	}
+	if !m.IsHidden(prs.PullRequest{Repo: "acme/api", HasConflicts: true}, nil) {
+		t.Error("expected a pull request with conflicts to be hidden")
+	}
+
+	m.SetQuery("has:changes")
+	if m.NeedsConflicts() {
+		t.Error("expected the query not to need conflicts checked")
+	}
+}
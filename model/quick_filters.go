@@ -7,19 +7,27 @@ import (
 
 type QuickFiltersModel struct {
 	ShowMineOnly bool
+	ShowDrafts   bool
 }
 
 func NewQuickFiltersModel() QuickFiltersModel {
 	return QuickFiltersModel{
 		ShowMineOnly: false,
+		ShowDrafts:   false,
 	}
 }
 
+// IsHidden hides drafts of others unless ShowDrafts is set, as they're not ready for review yet.
 func (m QuickFiltersModel) IsHidden(pr prs.PullRequest) bool {
-	return m.ShowMineOnly && !pr.IsMine
+	return (m.ShowMineOnly && !pr.IsMine) || (!m.ShowDrafts && pr.IsDraft && !pr.IsMine)
 }
 
 func (m *QuickFiltersModel) ToggleShowMineOnly() tea.Cmd {
 	m.ShowMineOnly = !m.ShowMineOnly
 	return UpdateListView
 }
+
+func (m *QuickFiltersModel) ToggleShowDrafts() tea.Cmd {
+	m.ShowDrafts = !m.ShowDrafts
+	return UpdateListView
+}
@@ -0,0 +1,360 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hejmsdz/bb/prs"
+)
+
+// membersTTL is how long the cached workspace members are used before they're loaded again.
+const membersTTL = 24 * time.Hour
+
+type CachedMembers struct {
+	Users     []prs.User
+	FetchedAt time.Time
+}
+
+// MsgPrUpdated replaces a single pull request, e.g. after it was changed from bb.
+type MsgPrUpdated struct {
+	Pr prs.PullRequest
+}
+
+type msgMembersLoaded struct {
+	workspace string
+	users     []prs.User
+	err       error
+}
+
+type msgReviewersFailed struct {
+	uid prs.Uid
+	err error
+}
+
+// reviewerCandidate is a workspace member or a group of them from the configuration.
+type reviewerCandidate struct {
+	name    string
+	users   []prs.User
+	isGroup bool
+}
+
+// ReviewersModel is a picker that adds and removes reviewers of a pull request.
+type ReviewersModel struct {
+	Members map[string]CachedMembers
+	client  prs.Client
+	groups  map[string][]string
+	pr      *prs.PullRequest
+	input   textinput.Model
+	cursor  int
+	loading bool
+	saving  bool
+	err     error
+}
+
+var (
+	reviewersTitleStyle    = lipgloss.NewStyle().Bold(true)
+	reviewersHintStyle     = lipgloss.NewStyle().Faint(true)
+	reviewersSelectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Bold(true)
+)
+
+func NewReviewersModel(client prs.Client, groups map[string][]string) ReviewersModel {
+	input := textinput.New()
+	input.Placeholder = "search by name"
+	return ReviewersModel{
+		Members: make(map[string]CachedMembers),
+		client:  client,
+		groups:  groups,
+		input:   input,
+	}
+}
+
+func workspaceOf(pr prs.PullRequest) string {
+	return strings.SplitN(pr.Repo, "/", 2)[0]
+}
+
+func (m ReviewersModel) IsVisible() bool {
+	return m.pr != nil
+}
+
+// Open shows the picker for the pull request, loading the members of its workspace unless they're cached.
+func (m *ReviewersModel) Open(pr prs.PullRequest) tea.Cmd {
+	m.pr = &pr
+	m.cursor = 0
+	m.err = nil
+	m.saving = false
+	m.input.SetValue("")
+	focusCmd := m.input.Focus()
+
+	workspace := workspaceOf(pr)
+	if cached, ok := m.Members[workspace]; ok && time.Since(cached.FetchedAt) < membersTTL {
+		return focusCmd
+	}
+	m.loading = true
+	client := m.client
+	return tea.Batch(focusCmd, func() tea.Msg {
+		users, err := client.GetMembers(workspace)
+		return msgMembersLoaded{workspace, users, err}
+	})
+}
+
+func (m *ReviewersModel) Close() {
+	m.pr = nil
+	m.input.Blur()
+}
+
+func sameUser(a prs.User, b prs.User) bool {
+	return (a.AccountId != "" && a.AccountId == b.AccountId) || (a.Uuid != "" && a.Uuid == b.Uuid)
+}
+
+func (m ReviewersModel) isReviewer(user prs.User) bool {
+	for _, reviewer := range m.pr.Reviewers {
+		if sameUser(reviewer, user) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveGroup finds the members named in the group by display name or account id.
+func resolveGroup(names []string, members []prs.User) []prs.User {
+	users := make([]prs.User, 0, len(names))
+	for _, name := range names {
+		for _, member := range members {
+			if strings.EqualFold(member.DisplayName, name) || member.AccountId == name {
+				users = append(users, member)
+				break
+			}
+		}
+	}
+	return users
+}
+
+// fuzzyScore matches the letters of the query in order, the fewer letters are skipped, the lower the score.
+func fuzzyScore(query string, name string) (int, bool) {
+	text := []rune(strings.ToLower(name))
+	score, pos := 0, 0
+	for _, q := range strings.ToLower(query) {
+		start := pos
+		for pos < len(text) && text[pos] != q {
+			pos++
+		}
+		if pos == len(text) {
+			return 0, false
+		}
+		score += pos - start
+		pos++
+	}
+	return score, true
+}
+
+func (m ReviewersModel) candidates() []reviewerCandidate {
+	members := m.Members[workspaceOf(*m.pr)].Users
+
+	all := make([]reviewerCandidate, 0, len(m.groups)+len(members))
+	groupNames := make([]string, 0, len(m.groups))
+	for name := range m.groups {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+	for _, name := range groupNames {
+		if users := resolveGroup(m.groups[name], members); len(users) > 0 {
+			all = append(all, reviewerCandidate{name, users, true})
+		}
+	}
+	for _, member := range members {
+		if member.DisplayName != m.pr.Author {
+			all = append(all, reviewerCandidate{member.DisplayName, []prs.User{member}, false})
+		}
+	}
+
+	query := m.input.Value()
+	scores := make(map[string]int)
+	matching := make([]reviewerCandidate, 0, len(all))
+	for _, candidate := range all {
+		if score, ok := fuzzyScore(query, candidate.name); ok {
+			scores[candidate.name] = score
+			matching = append(matching, candidate)
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		return scores[matching[i].name] < scores[matching[j].name]
+	})
+	return matching
+}
+
+// toggledReviewers removes the users from the reviewers if all of them are reviewers already, otherwise adds the missing ones.
+func toggledReviewers(current []prs.User, users []prs.User) []prs.User {
+	isReviewer := func(user prs.User) bool {
+		for _, reviewer := range current {
+			if sameUser(reviewer, user) {
+				return true
+			}
+		}
+		return false
+	}
+	allReviewers := true
+	for _, user := range users {
+		allReviewers = allReviewers && isReviewer(user)
+	}
+
+	reviewers := make([]prs.User, 0, len(current)+len(users))
+	for _, reviewer := range current {
+		inCandidate := false
+		for _, user := range users {
+			inCandidate = inCandidate || sameUser(reviewer, user)
+		}
+		if !allReviewers || !inCandidate {
+			reviewers = append(reviewers, reviewer)
+		}
+	}
+	if !allReviewers {
+		for _, user := range users {
+			if !isReviewer(user) {
+				reviewers = append(reviewers, user)
+			}
+		}
+	}
+	return reviewers
+}
+
+// toggle applies the candidate to the reviewers loaded afresh, as the list may be out of date
+// and the whole set of reviewers is replaced.
+func (m *ReviewersModel) toggle(candidate reviewerCandidate) tea.Cmd {
+	m.saving = true
+	m.err = nil
+	pr, client := *m.pr, m.client
+	return func() tea.Msg {
+		fresh, err := client.GetPullRequest(pr.Repo, pr.Id)
+		if err != nil {
+			return msgReviewersFailed{pr.Uid(), err}
+		}
+		updated, err := client.SetReviewers(fresh, toggledReviewers(fresh.Reviewers, candidate.users))
+		if err != nil {
+			return msgReviewersFailed{pr.Uid(), err}
+		}
+		return MsgPrUpdated{updated}
+	}
+}
+
+func (m ReviewersModel) Update(msg tea.Msg) (ReviewersModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case msgMembersLoaded:
+		m.loading = false
+		if msg.err != nil {
+			m.err = fmt.Errorf("could not load members of %s: %w", msg.workspace, msg.err)
+			return m, nil
+		}
+		if m.Members == nil {
+			m.Members = make(map[string]CachedMembers)
+		}
+		m.Members[msg.workspace] = CachedMembers{msg.users, time.Now()}
+
+	case MsgPrUpdated:
+		if m.pr != nil && m.pr.Uid() == msg.Pr.Uid() {
+			m.pr = &msg.Pr
+			m.saving = false
+		}
+
+	case msgReviewersFailed:
+		if m.pr != nil && m.pr.Uid() == msg.uid {
+			m.saving = false
+			m.err = msg.err
+		}
+
+	case tea.KeyMsg:
+		if m.pr == nil {
+			return m, nil
+		}
+		switch msg.String() {
+		case "esc":
+			m.Close()
+			return m, nil
+		case "up", "ctrl+p":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+			return m, nil
+		case "down", "ctrl+n":
+			if m.cursor < len(m.candidates())-1 {
+				m.cursor++
+			}
+			return m, nil
+		case "enter":
+			candidates := m.candidates()
+			if m.saving || m.cursor >= len(candidates) {
+				return m, nil
+			}
+			return m, m.toggle(candidates[m.cursor])
+		}
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		m.cursor = 0
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m ReviewersModel) checkbox(candidate reviewerCandidate) string {
+	count := 0
+	for _, user := range candidate.users {
+		if m.isReviewer(user) {
+			count++
+		}
+	}
+	switch {
+	case count == 0:
+		return "[ ]"
+	case count == len(candidate.users):
+		return "[x]"
+	}
+	return "[-]"
+}
+
+func (m ReviewersModel) View(height int) string {
+	if m.pr == nil {
+		return ""
+	}
+	lines := []string{
+		reviewersTitleStyle.Render("Reviewers of " + m.pr.Title),
+		m.input.View(),
+		reviewersHintStyle.Render("enter add/remove • ↑/↓ move • esc close"),
+	}
+	switch {
+	case m.loading:
+		lines = append(lines, reviewersHintStyle.Render("Loading members…"))
+	case m.saving:
+		lines = append(lines, reviewersHintStyle.Render("Saving…"))
+	case m.err != nil:
+		lines = append(lines, m.err.Error())
+	}
+	lines = append(lines, "")
+
+	candidates := m.candidates()
+	visible := height - len(lines)
+	first := 0
+	if visible > 0 && m.cursor >= visible {
+		first = m.cursor - visible + 1
+	}
+	for i := first; i < len(candidates) && len(lines) < height; i++ {
+		candidate := candidates[i]
+		label := candidate.name
+		if candidate.isGroup {
+			names := make([]string, len(candidate.users))
+			for j, user := range candidate.users {
+				names[j] = user.DisplayName
+			}
+			label = fmt.Sprintf("👥 %s: %s", candidate.name, strings.Join(names, ", "))
+		}
+		line := m.checkbox(candidate) + " " + label
+		if i == m.cursor {
+			line = reviewersSelectedStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,25 @@
+package model
+
+import "testing"
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		query   string
+		name    string
+		score   int
+		matches bool
+	}{
+		{"", "Alice Smith", 0, true},
+		{"ali", "Alice Smith", 0, true},
+		{"asm", "Alice Smith", 5, true},
+		{"SMITH", "Alice Smith", 6, true},
+		{"ai", "Alice Smith", 1, true},
+		{"sa", "Alice Smith", 0, false},
+	}
+	for _, test := range tests {
+		score, matches := fuzzyScore(test.query, test.name)
+		if score != test.score || matches != test.matches {
+			t.Errorf("%q in %q: expected %d %v, got %d %v", test.query, test.name, test.score, test.matches, score, matches)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package model
+
+import (
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hejmsdz/bb/prs"
+)
+
+type SortOrder string
+
+const (
+	SortByUpdated   SortOrder = "updated"
+	SortByCreated   SortOrder = "created"
+	SortByRepo      SortOrder = "repository"
+	SortByAuthor    SortOrder = "author"
+	SortByApprovals SortOrder = "approvals"
+	SortByAttention SortOrder = "attention"
+)
+
+var sortOrders = []SortOrder{SortByUpdated, SortByCreated, SortByRepo, SortByAuthor, SortByApprovals, SortByAttention}
+
+type Grouping string
+
+const (
+	GroupByNone   Grouping = "none"
+	GroupByRepo   Grouping = "repository"
+	GroupByStatus Grouping = "status"
+)
+
+var groupings = []Grouping{GroupByNone, GroupByRepo, GroupByStatus}
+
+type SortingModel struct {
+	SortBy  SortOrder
+	GroupBy Grouping
+}
+
+func NewSortingModel() SortingModel {
+	return SortingModel{
+		SortBy:  SortByUpdated,
+		GroupBy: GroupByNone,
+	}
+}
+
+func (m *SortingModel) CycleSortOrder() tea.Cmd {
+	next := 0
+	for i, order := range sortOrders {
+		if order == m.SortBy {
+			next = (i + 1) % len(sortOrders)
+		}
+	}
+	m.SortBy = sortOrders[next]
+	return UpdateListView
+}
+
+func (m *SortingModel) CycleGrouping() tea.Cmd {
+	next := 0
+	for i, grouping := range groupings {
+		if grouping == m.GroupBy {
+			next = (i + 1) % len(groupings)
+		}
+	}
+	m.GroupBy = groupings[next]
+	return UpdateListView
+}
+
+// Group returns the name of the section the pull request belongs to,
+// or an empty string when the list is not grouped.
+func (m SortingModel) Group(pr prs.PullRequest, changes []string) string {
+	switch m.GroupBy {
+	case GroupByRepo:
+		return pr.Repo
+	case GroupByStatus:
+		return Classify(pr, changes).String()
+	}
+	return ""
+}
+
+func (m SortingModel) groupLess(a, b prs.PullRequest, whatChanged WhatChangedModel) (less bool, equal bool) {
+	switch m.GroupBy {
+	case GroupByRepo:
+		return a.Repo < b.Repo, a.Repo == b.Repo
+	case GroupByStatus:
+		sa, sb := Classify(a, whatChanged.WhatChanged(a)), Classify(b, whatChanged.WhatChanged(b))
+		return sa < sb, sa == sb
+	}
+	return false, true
+}
+
+func (m SortingModel) Sort(prList []prs.PullRequest, whatChanged WhatChangedModel) []prs.PullRequest {
+	sorted := make([]prs.PullRequest, len(prList))
+	copy(sorted, prList)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if less, equal := m.groupLess(a, b, whatChanged); !equal {
+			return less
+		}
+
+		switch m.SortBy {
+		case SortByCreated:
+			if !a.CreatedOn.Equal(b.CreatedOn) {
+				return a.CreatedOn.After(b.CreatedOn)
+			}
+		case SortByRepo:
+			if a.Repo != b.Repo {
+				return a.Repo < b.Repo
+			}
+		case SortByAuthor:
+			if a.Author != b.Author {
+				return strings.ToLower(a.Author) < strings.ToLower(b.Author)
+			}
+		case SortByApprovals:
+			if a.ApprovedCount != b.ApprovedCount {
+				return a.ApprovedCount > b.ApprovedCount
+			}
+		case SortByAttention:
+			sa := AttentionScore(a, whatChanged.WhatChanged(a))
+			sb := AttentionScore(b, whatChanged.WhatChanged(b))
+			if sa != sb {
+				return sa > sb
+			}
+		}
+		return a.UpdatedOn.After(b.UpdatedOn)
+	})
+
+	return sorted
+}
+
+// AttentionScore ranks how much a pull request needs my action, higher is more urgent.
+// It follows the inbox state, pull requests with unseen changes go first within a state.
+func AttentionScore(pr prs.PullRequest, changes []string) int {
+	score := 2 * (len(inboxStates) - int(Classify(pr, changes)))
+	if len(changes) > 0 {
+		score++
+	}
+	return score
+}
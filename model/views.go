@@ -0,0 +1,159 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hejmsdz/bb/prs"
+)
+
+type View struct {
+	Name   string
+	Filter string
+	Sort   SortOrder
+}
+
+var DefaultViews = []View{
+	{Name: "Needs my review", Filter: "state:awaiting-review", Sort: SortByAttention},
+	{Name: "My PRs", Filter: "author:me"},
+	{Name: "Waiting on others", Filter: "state:waiting"},
+	{Name: "Stale", Filter: "updated:>7d", Sort: SortByUpdated},
+}
+
+type viewCount struct {
+	matching int
+	unread   int
+}
+
+type ViewsModel struct {
+	Active  int
+	views   []View
+	filters [][]Predicate
+	counts  []viewCount
+	// sortCycled is set once the sort order is cycled, the sort of the active view is then only where it started.
+	sortCycled bool
+}
+
+var (
+	activeTabStyle   = lipgloss.NewStyle().Bold(true).Underline(true)
+	inactiveTabStyle = lipgloss.NewStyle().Faint(true)
+	unreadBadgeStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Bold(true)
+)
+
+// NewViewsModel creates the tab bar, the first tab always shows all pull requests.
+func NewViewsModel(views []View) ViewsModel {
+	allViews := append([]View{{Name: "All"}}, views...)
+	filters := make([][]Predicate, len(allViews))
+	for i, view := range allViews {
+		// Invalid filters are reported by Validate.
+		filters[i], _ = ParseQuery(view.Filter)
+	}
+	return ViewsModel{
+		views:   allViews,
+		filters: filters,
+		counts:  make([]viewCount, len(allViews)),
+	}
+}
+
+func (m *ViewsModel) Select(index int) tea.Cmd {
+	if index < 0 || index >= len(m.views) {
+		return nil
+	}
+	m.Active = index
+	m.sortCycled = false
+	return UpdateListView
+}
+
+func (m ViewsModel) activeIndex() int {
+	if m.Active < 0 || m.Active >= len(m.views) {
+		return 0
+	}
+	return m.Active
+}
+
+func (m ViewsModel) ActiveView() View {
+	return m.views[m.activeIndex()]
+}
+
+// Sort returns the sort order of the active view, or an empty string if it has none or it was cycled since.
+func (m ViewsModel) Sort() SortOrder {
+	if m.sortCycled {
+		return ""
+	}
+	return m.ActiveView().Sort
+}
+
+// CycleSort lets the sort order chosen by the user override the one of the active view until another view is selected.
+func (m *ViewsModel) CycleSort() {
+	m.sortCycled = true
+}
+
+func (m ViewsModel) matches(index int, pr prs.PullRequest, changes []string) bool {
+	for _, predicate := range m.filters[index] {
+		if !predicate(pr, changes) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m ViewsModel) IsHidden(pr prs.PullRequest, changes []string) bool {
+	return !m.matches(m.activeIndex(), pr, changes)
+}
+
+// NeedsConflicts tells whether any view filters by has:conflicts, as it is needed to count the pull requests of every tab.
+func (m ViewsModel) NeedsConflicts() bool {
+	for _, view := range m.views {
+		if usesConflicts(view.Filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// Count recomputes the badges of all tabs.
+// The changes function returns what changed in a pull request since it was last seen.
+func (m *ViewsModel) Count(prList []prs.PullRequest, changes func(prs.PullRequest) []string) {
+	m.counts = make([]viewCount, len(m.views))
+	for _, pr := range prList {
+		prChanges := changes(pr)
+		for i := range m.views {
+			if !m.matches(i, pr, prChanges) {
+				continue
+			}
+			m.counts[i].matching++
+			if len(prChanges) > 0 {
+				m.counts[i].unread++
+			}
+		}
+	}
+}
+
+// Validate returns an error for the first view with an invalid filter.
+func (m ViewsModel) Validate() error {
+	for _, view := range m.views {
+		if _, err := ParseQuery(view.Filter); err != nil {
+			return fmt.Errorf("view %q: %w", view.Name, err)
+		}
+	}
+	return nil
+}
+
+func (m ViewsModel) View() string {
+	tabs := make([]string, 0, len(m.views))
+	for i, view := range m.views {
+		label := fmt.Sprintf("%d %s %d", i+1, view.Name, m.counts[i].matching)
+		style := inactiveTabStyle
+		if i == m.activeIndex() {
+			style = activeTabStyle
+		}
+		tab := style.Render(label)
+		if m.counts[i].unread > 0 {
+			tab += " " + unreadBadgeStyle.Render(fmt.Sprintf("🔔%d", m.counts[i].unread))
+		}
+		tabs = append(tabs, tab)
+	}
+	return strings.Join(tabs, "  │  ")
+}
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"fmt"
+	"path"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hejmsdz/bb/prs"
+)
+
+// WatchRule matches pull requests to follow even when I'm not participating in them.
+// Every field that is set must match, Repo and Target are glob patterns.
+type WatchRule struct {
+	Repo   string
+	Author string
+	Target string
+}
+
+func (r WatchRule) Validate() error {
+	if r.Repo == "" && r.Author == "" && r.Target == "" {
+		return fmt.Errorf("watch rule without Repo, Author or Target")
+	}
+	for _, pattern := range []string{r.Repo, r.Target} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q in watch rule: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+func (r WatchRule) Matches(pr prs.PullRequest) bool {
+	return (r.Repo == "" || matchPattern(r.Repo, pr.Repo)) &&
+		(r.Author == "" || matchPattern(r.Author, pr.Author)) &&
+		(r.Target == "" || matchPattern(r.Target, pr.TargetBranch))
+}
+
+type Involvement int
+
+const (
+	Involved Involvement = iota
+	Watching
+	NotInvolved
+)
+
+type WatchModel struct {
+	ShowAll bool
+	Watched map[prs.Uid]bool
+	rules   []WatchRule
+}
+
+func NewWatchModel(rules []WatchRule) WatchModel {
+	return WatchModel{
+		Watched: make(map[prs.Uid]bool),
+		rules:   rules,
+	}
+}
+
+func (m WatchModel) Validate() error {
+	for _, rule := range m.rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m WatchModel) IsWatchedByRule(pr prs.PullRequest) bool {
+	for _, rule := range m.rules {
+		if rule.Matches(pr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m WatchModel) Involvement(pr prs.PullRequest) Involvement {
+	if pr.IsMine || pr.AmIParticipating {
+		return Involved
+	}
+	if m.Watched[pr.Uid()] || m.IsWatchedByRule(pr) {
+		return Watching
+	}
+	return NotInvolved
+}
+
+// IsRelevant tells whether I'm involved in the pull request or watching it.
+func (m WatchModel) IsRelevant(pr prs.PullRequest) bool {
+	return m.Involvement(pr) != NotInvolved
+}
+
+func (m WatchModel) IsHidden(pr prs.PullRequest) bool {
+	return !m.ShowAll && !m.IsRelevant(pr)
+}
+
+// ToggleWatch returns whether the pull request is watched now.
+func (m WatchModel) ToggleWatch(pr prs.PullRequest) (bool, tea.Cmd) {
+	uid := pr.Uid()
+	if m.Watched[uid] {
+		delete(m.Watched, uid)
+	} else {
+		m.Watched[uid] = true
+	}
+	return m.Watched[uid], UpdateListView
+}
+
+func (m *WatchModel) ToggleShowAll() tea.Cmd {
+	m.ShowAll = !m.ShowAll
+	return UpdateListView
+}
+
+// Prune forgets watched pull requests which are no longer open.
+func (m WatchModel) Prune(open map[prs.Uid]bool) {
+	for uid := range m.Watched {
+		if !open[uid] {
+			delete(m.Watched, uid)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/hejmsdz/bb/prs"
+)
+
+func TestInvolvement(t *testing.T) {
+	m := NewWatchModel([]WatchRule{
+		{Repo: "acme/api"},
+		{Author: "Alice", Target: "release/*"},
+	})
+	tests := []struct {
+		name string
+		pr   prs.PullRequest
+		want Involvement
+	}{
+		{"mine", prs.PullRequest{Repo: "acme/web", IsMine: true}, Involved},
+		{"reviewing", prs.PullRequest{Repo: "acme/web", AmIParticipating: true}, Involved},
+		{"watched repo", prs.PullRequest{Repo: "acme/api"}, Watching},
+		{"author and target", prs.PullRequest{Repo: "acme/web", Author: "alice", TargetBranch: "release/1.2"}, Watching},
+		{"author only", prs.PullRequest{Repo: "acme/web", Author: "Alice", TargetBranch: "main"}, NotInvolved},
+		{"other", prs.PullRequest{Repo: "acme/web", Author: "Bob"}, NotInvolved},
+	}
+	for _, test := range tests {
+		if got := m.Involvement(test.pr); got != test.want {
+			t.Errorf("%s: expected %d, got %d", test.name, test.want, got)
+		}
+	}
+
+	if err := NewWatchModel([]WatchRule{{}}).Validate(); err == nil {
+		t.Error("expected an error for an empty rule")
+	}
+}
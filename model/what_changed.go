@@ -1,68 +1,249 @@
 package model
 
 import (
+	"fmt"
+	"strings"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/hejmsdz/bb/prs"
 )
 
 type WhatChangedModel struct {
 	PrevPrs map[prs.Uid]prs.PullRequest
+	// NewPrs were opened since bb first loaded the list, until dismissed.
+	NewPrs map[prs.Uid]bool
 }
 
 func NewWhatChangedModel() WhatChangedModel {
 	return WhatChangedModel{
 		PrevPrs: make(map[prs.Uid]prs.PullRequest),
+		NewPrs:  make(map[prs.Uid]bool),
 	}
 }
 
+// WhatChanged lists the kinds of changes since the snapshot, e.g. for the list item.
 func (m WhatChangedModel) WhatChanged(pr prs.PullRequest) []string {
-	prevPr, exists := m.PrevPrs[pr.Uid()]
-	if !exists {
-		return []string{}
+	changes := m.Changes(pr)
+	kinds := make([]string, len(changes))
+	for i, change := range changes {
+		kinds[i] = change.Kind
 	}
-	return findUpdates(prevPr, pr)
+	return kinds
+}
+
+func (m WhatChangedModel) Changes(pr prs.PullRequest) []Change {
+	changes := []Change{}
+	if m.NewPrs[pr.Uid()] {
+		changes = append(changes, Change{Kind: changeNew, Users: []string{pr.Author}})
+	}
+	if prevPr, exists := m.PrevPrs[pr.Uid()]; exists {
+		changes = append(changes, findUpdates(prevPr, pr)...)
+	}
+	return changes
+}
+
+// WithDetails fills in who pushed the commits and who commented, as fetched from the API.
+func WithDetails(changes []Change, commits []prs.Commit, comments []prs.Comment) []Change {
+	detailed := make([]Change, len(changes))
+	for i, change := range changes {
+		switch change.Kind {
+		case changeCommitted:
+			change.Count = len(commits)
+			change.Users = make([]string, 0, len(commits))
+			for _, commit := range commits {
+				change.Users = append(change.Users, commit.Author)
+			}
+			change.Users = uniqueUsers(change.Users)
+		case changeCommented:
+			change.Users = make([]string, 0, len(comments))
+			for _, comment := range comments {
+				change.Users = append(change.Users, comment.Author)
+			}
+			change.Users = uniqueUsers(change.Users)
+		}
+		detailed[i] = change
+	}
+	return detailed
 }
 
 func (m WhatChangedModel) DismissChanges(pr prs.PullRequest) tea.Cmd {
 	uid := pr.Uid()
 	m.PrevPrs[uid] = pr
+	delete(m.NewPrs, uid)
 	return UpdateListView
 }
 
 func (m WhatChangedModel) Update(msg tea.Msg) (WhatChangedModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case MsgPrsLoaded:
+		if m.NewPrs == nil {
+			m.NewPrs = make(map[prs.Uid]bool)
+		}
+		isFirstLoad := len(m.PrevPrs) == 0
 		for _, oldPr := range msg.prs {
 			_, isCached := m.PrevPrs[oldPr.Uid()]
 			if !isCached {
 				m.PrevPrs[oldPr.Uid()] = oldPr
+				m.NewPrs[oldPr.Uid()] = !isFirstLoad
 			}
 		}
 	}
 	return m, nil
 }
 
-func findUpdates(oldPr prs.PullRequest, newPr prs.PullRequest) []string {
-	updates := make([]string, 0)
+// Prune forgets the snapshots of pull requests which are no longer open.
+func (m WhatChangedModel) Prune(open map[prs.Uid]bool) {
+	for uid := range m.PrevPrs {
+		if !open[uid] {
+			delete(m.PrevPrs, uid)
+		}
+	}
+	for uid := range m.NewPrs {
+		if !open[uid] || !m.NewPrs[uid] {
+			delete(m.NewPrs, uid)
+		}
+	}
+}
+
+const (
+	changeCommitted        = "commited"
+	changeCommented        = "commented"
+	changeApproved         = "approved"
+	changeRequestedChanges = "changesRequested"
+	changeMyReview         = "youReviewed"
+	changeNew              = "new"
+	changeReadyForReview   = "readyForReview"
+)
+
+// Change is one kind of update of a pull request since its snapshot was taken.
+type Change struct {
+	Kind string
+	// Users who approved, requested changes, pushed the commits or commented, if known.
+	Users []string
+	// Count of new commits, 0 until the details are loaded.
+	Count int
+	// From and To are the old and new review or comment counts.
+	From int
+	To   int
+}
+
+func (c Change) String() string {
+	by := ""
+	if len(c.Users) > 0 {
+		by = " by " + strings.Join(c.Users, ", ")
+	}
+	switch c.Kind {
+	case changeCommitted:
+		if c.Count == 0 {
+			return "new commits" + by
+		}
+		return plural(c.Count, "new commit") + by
+	case changeCommented:
+		if c.To < c.From {
+			return fmt.Sprintf("comments %d → %d", c.From, c.To)
+		}
+		return plural(c.To-c.From, "new comment") + by
+	case changeApproved:
+		if len(c.Users) > 0 {
+			return strings.Join(c.Users, ", ") + " approved"
+		}
+		return fmt.Sprintf("approvals %d → %d", c.From, c.To)
+	case changeRequestedChanges:
+		if len(c.Users) > 0 {
+			return strings.Join(c.Users, ", ") + " requested changes"
+		}
+		return fmt.Sprintf("change requests %d → %d", c.From, c.To)
+	case changeMyReview:
+		switch prs.Review(c.To) {
+		case prs.Approved:
+			return "you approved"
+		case prs.RequestedChanges:
+			return "you requested changes"
+		}
+		return "you withdrew your review"
+	case changeNew:
+		return "new pull request" + by
+	case changeReadyForReview:
+		return "ready for review"
+	}
+	return c.Kind + by
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// Summary describes the changes in one line, e.g. "Alice approved, 2 new commits by Bob".
+func Summary(changes []Change) string {
+	parts := make([]string, len(changes))
+	for i, change := range changes {
+		parts[i] = change.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// addedUsers lists the users who are in the new list, but weren't in the old one.
+func addedUsers(oldUsers []string, users []string) []string {
+	added := make([]string, 0)
+	for _, user := range users {
+		found := false
+		for _, oldUser := range oldUsers {
+			found = found || oldUser == user
+		}
+		if !found {
+			added = append(added, user)
+		}
+	}
+	return added
+}
+
+func uniqueUsers(users []string) []string {
+	unique := make([]string, 0)
+	for _, user := range users {
+		if len(addedUsers(unique, []string{user})) > 0 {
+			unique = append(unique, user)
+		}
+	}
+	return unique
+}
+
+// addedSince lists the users added since the old snapshot. Snapshots saved before the users were
+// recorded count them without naming them, so who was added is unknown then.
+func addedSince(oldUsers []string, oldCount int, newUsers []string) []string {
+	if oldUsers == nil && oldCount > 0 {
+		return nil
+	}
+	return addedUsers(oldUsers, newUsers)
+}
+
+func findUpdates(oldPr prs.PullRequest, newPr prs.PullRequest) []Change {
+	updates := make([]Change, 0)
+
+	if oldPr.IsDraft && !newPr.IsDraft {
+		updates = append(updates, Change{Kind: changeReadyForReview})
+	}
 
 	if newPr.LastCommit != oldPr.LastCommit {
-		updates = append(updates, "commited")
+		updates = append(updates, Change{Kind: changeCommitted})
 	}
 
 	if newPr.CommentsCount != oldPr.CommentsCount {
-		updates = append(updates, "commented")
+		updates = append(updates, Change{Kind: changeCommented, From: oldPr.CommentsCount, To: newPr.CommentsCount})
 	}
 
-	if newPr.ApprovedCount != oldPr.ApprovedCount {
-		updates = append(updates, "approved")
+	if approvers := addedSince(oldPr.Approvers, oldPr.ApprovedCount, newPr.Approvers); newPr.ApprovedCount != oldPr.ApprovedCount || len(approvers) > 0 {
+		updates = append(updates, Change{Kind: changeApproved, Users: approvers, From: oldPr.ApprovedCount, To: newPr.ApprovedCount})
 	}
 
-	if newPr.RequestedChangesCount != oldPr.RequestedChangesCount {
-		updates = append(updates, "changesRequested")
+	if requesters := addedSince(oldPr.ChangesRequestedBy, oldPr.RequestedChangesCount, newPr.ChangesRequestedBy); newPr.RequestedChangesCount != oldPr.RequestedChangesCount || len(requesters) > 0 {
+		updates = append(updates, Change{Kind: changeRequestedChanges, Users: requesters, From: oldPr.RequestedChangesCount, To: newPr.RequestedChangesCount})
 	}
 
-	if newPr.MyReview != oldPr.MyReview {
-		updates = append(updates, "youReviewed")
+	if newPr.MyReview != oldPr.MyReview && oldPr.MyReview != prs.ReviewUnknown {
+		updates = append(updates, Change{Kind: changeMyReview, From: int(oldPr.MyReview), To: int(newPr.MyReview)})
 	}
 
 	return updates
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/hejmsdz/bb/prs"
+)
+
+func TestSummary(t *testing.T) {
+	oldPr := prs.PullRequest{LastCommit: "aaa", CommentsCount: 1, ApprovedCount: 1, Approvers: []string{"Carol"}}
+	newPr := prs.PullRequest{LastCommit: "ccc", CommentsCount: 4, ApprovedCount: 2, Approvers: []string{"Carol", "Alice"}, MyReview: prs.Approved}
+
+	changes := findUpdates(oldPr, newPr)
+	if got, want := Summary(changes), "new commits, 3 new comments, Alice approved, you approved"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	commits := []prs.Commit{{Hash: "ccc", Author: "Bob"}, {Hash: "bbb", Author: "Bob"}}
+	comments := []prs.Comment{{Author: "Carol"}}
+	if got, want := Summary(WithDetails(changes, commits, comments)), "2 new commits by Bob, 3 new comments by Carol, Alice approved, you approved"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSnapshotWithoutUsers(t *testing.T) {
+	oldPr := prs.PullRequest{ApprovedCount: 1, RequestedChangesCount: 1}
+	newPr := prs.PullRequest{ApprovedCount: 1, Approvers: []string{"Alice"}, RequestedChangesCount: 1, ChangesRequestedBy: []string{"Bob"}}
+	if changes := findUpdates(oldPr, newPr); len(changes) != 0 {
+		t.Errorf("expected no changes, got %q", Summary(changes))
+	}
+
+	newPr.ApprovedCount, newPr.Approvers = 2, []string{"Alice", "Carol"}
+	if got, want := Summary(findUpdates(oldPr, newPr)), "approvals 1 → 2"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSummaryOfWithdrawnReviews(t *testing.T) {
+	oldPr := prs.PullRequest{ApprovedCount: 2, RequestedChangesCount: 1, MyReview: prs.RequestedChanges}
+	newPr := prs.PullRequest{ApprovedCount: 1}
+
+	if got, want := Summary(findUpdates(oldPr, newPr)), "approvals 2 → 1, change requests 1 → 0, you withdrew your review"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
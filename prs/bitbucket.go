@@ -1,19 +1,34 @@
 package prs
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
+// maxConcurrentRequests limits how many repositories are loaded at the same time,
+// whole workspaces are expanded into many of them.
+const maxConcurrentRequests = 8
+
 type BitbucketClient struct {
 	config     AccountConfig
 	apiUrl     string
 	userId     string
+	userUuid   string
 	httpClient *http.Client
+	repoCache  *repositoryCache
+}
+
+type bbErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
 }
 
 type bbPullRequestsResponse struct {
@@ -23,6 +38,7 @@ type bbPullRequestsResponse struct {
 type bbUser struct {
 	DisplayName string `json:"display_name"`
 	AccountId   string `json:"account_id"`
+	Uuid        string `json:"uuid"`
 }
 
 type bbLink struct {
@@ -41,9 +57,39 @@ type bbCommit struct {
 	Hash string `json:"hash"`
 }
 
+type bbCommitAuthor struct {
+	Raw  string  `json:"raw"`
+	User *bbUser `json:"user"`
+}
+
+type bbCommitDetails struct {
+	Hash   string         `json:"hash"`
+	Date   string         `json:"date"`
+	Author bbCommitAuthor `json:"author"`
+}
+
+type bbCommitsResponse struct {
+	Values []bbCommitDetails `json:"values"`
+}
+
+type bbComment struct {
+	CreatedOn string `json:"created_on"`
+	User      bbUser `json:"user"`
+	Deleted   bool   `json:"deleted"`
+}
+
+type bbCommentsResponse struct {
+	Values []bbComment `json:"values"`
+}
+
+type bbRepository struct {
+	FullName string `json:"full_name"`
+}
+
 type bbEndpoint struct {
-	Branch bbBranch `json:"branch"`
-	Commit bbCommit `json:"commit"`
+	Branch     bbBranch     `json:"branch"`
+	Commit     bbCommit     `json:"commit"`
+	Repository bbRepository `json:"repository"`
 }
 
 type bbParticipant struct {
@@ -55,6 +101,11 @@ type bbParticipant struct {
 type bbPullRequest struct {
 	Id           int             `json:"id"`
 	Title        string          `json:"title"`
+	Description  string          `json:"description"`
+	State        string          `json:"state"`
+	Draft        bool            `json:"draft"`
+	ClosedBy     *bbUser         `json:"closed_by"`
+	CreatedOn    string          `json:"created_on"`
 	UpdatedOn    string          `json:"updated_on"`
 	CommentCount int             `json:"comment_count"`
 	Author       bbUser          `json:"author"`
@@ -65,20 +116,31 @@ type bbPullRequest struct {
 }
 
 func CreateBitbucketClient(config AccountConfig) (BitbucketClient, bool) {
+	return NewBitbucketClient(config, &http.Client{})
+}
+
+// NewBitbucketClient creates a client that sends its requests through httpClient,
+// e.g. one with a RecordingTransport or a ReplayTransport.
+func NewBitbucketClient(config AccountConfig, httpClient *http.Client) (BitbucketClient, bool) {
 	c := BitbucketClient{
-		config,
-		"https://api.bitbucket.org/2.0/",
-		"",
-		&http.Client{},
+		config:     config,
+		apiUrl:     "https://api.bitbucket.org/2.0/",
+		httpClient: httpClient,
+		repoCache:  newRepositoryCache(config.RepositoryCachePath),
 	}
 	user := c.getUser()
 	if user != nil {
 		c.userId = user.AccountId
+		c.userUuid = user.Uuid
 	}
 
 	return c, user != nil
 }
 
+func (u bbUser) toUser() User {
+	return User{AccountId: u.AccountId, Uuid: u.Uuid, DisplayName: u.DisplayName}
+}
+
 func (c BitbucketClient) get(path string) (*http.Response, error) {
 	req, err := http.NewRequest("GET", c.apiUrl+path, nil)
 	if err != nil {
@@ -88,11 +150,52 @@ func (c BitbucketClient) get(path string) (*http.Response, error) {
 	return c.httpClient.Do(req)
 }
 
-func (c BitbucketClient) getUser() *bbUser {
-	resp, _ := c.get("user")
+// putJSON sends body as JSON and decodes the response of a successful request into v.
+func (c BitbucketClient) putJSON(path string, body interface{}, v interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("PUT", c.apiUrl+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.config.Username, c.config.Password)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
+		var bbErr bbErrorResponse
+		if json.NewDecoder(resp.Body).Decode(&bbErr) == nil && bbErr.Error.Message != "" {
+			return fmt.Errorf("%s", bbErr.Error.Message)
+		}
+		return fmt.Errorf("%s: %s", strings.SplitN(path, "?", 2)[0], resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// getJSON decodes the response of a successful request into v.
+func (c BitbucketClient) getJSON(path string, v interface{}) error {
+	resp, err := c.get(path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("%s: %s", strings.SplitN(path, "?", 2)[0], resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (c BitbucketClient) getUser() *bbUser {
+	resp, err := c.get("user")
+	if err != nil || resp.StatusCode != 200 {
 		return nil
 	}
+	defer resp.Body.Close()
 	var user *bbUser
 	json.NewDecoder(resp.Body).Decode(&user)
 	return user
@@ -105,6 +208,7 @@ func processReviewers(participants []bbParticipant, pr *PullRequest, myUserId st
 		}
 
 		pr.ReviewersCount++
+		pr.Reviewers = append(pr.Reviewers, part.User.toUser())
 
 		if part.User.AccountId == myUserId {
 			pr.AmIParticipating = true
@@ -117,8 +221,11 @@ func processReviewers(participants []bbParticipant, pr *PullRequest, myUserId st
 
 		if part.State == "approved" {
 			pr.ApprovedCount++
+			pr.Approvers = append(pr.Approvers, part.User.DisplayName)
+			pr.ApprovedBy = append(pr.ApprovedBy, part.User.toUser())
 		} else if part.State == "changes_requested" {
 			pr.RequestedChangesCount++
+			pr.ChangesRequestedBy = append(pr.ChangesRequestedBy, part.User.DisplayName)
 		}
 	}
 
@@ -127,57 +234,313 @@ func processReviewers(participants []bbParticipant, pr *PullRequest, myUserId st
 var prFieldsStr = strings.Join([]string{
 	"values.id",
 	"values.title",
+	"values.draft",
+	"values.created_on",
 	"values.updated_on",
 	"values.comment_count",
 	"values.author.display_name",
 	"values.author.account_id",
 	"values.source.branch.name",
 	"values.source.commit.hash",
+	"values.source.repository.full_name",
 	"values.destination.branch.name",
 	"values.links.html.href",
 	"values.participants.role",
 	"values.participants.state",
 	"values.participants.user.account_id",
+	"values.participants.user.display_name",
+	"values.participants.user.uuid",
 }, ",")
 
-func (c BitbucketClient) getPullRequests(repo string) []PullRequest {
-	resp, _ := c.get(fmt.Sprintf("repositories/%s/pullrequests?state=OPEN&pagelen=50&fields=%s", repo, prFieldsStr))
-	var bbPrs *bbPullRequestsResponse
-	json.NewDecoder(resp.Body).Decode(&bbPrs)
+const bbTimeLayout = "2006-01-02T15:04:05.000000-07:00"
+
+func (c BitbucketClient) toPullRequest(repo string, bbPr bbPullRequest) PullRequest {
+	pr := PullRequest{
+		Id:            fmt.Sprintf("%d", bbPr.Id),
+		Repo:          repo,
+		SourceRepo:    bbPr.Source.Repository.FullName,
+		Title:         bbPr.Title,
+		Description:   bbPr.Description,
+		Author:        bbPr.Author.DisplayName,
+		LastCommit:    bbPr.Source.Commit.Hash,
+		Branch:        bbPr.Source.Branch.Name,
+		TargetBranch:  bbPr.Destination.Branch.Name,
+		CommentsCount: bbPr.CommentCount,
+		State:         State(bbPr.State),
+		IsDraft:       bbPr.Draft,
+		Url:           bbPr.Links.Html.Href,
+		IsMine:        bbPr.Author.AccountId == c.userId,
+	}
+	if pr.State == "" {
+		pr.State = StateOpen
+	}
+	if bbPr.ClosedBy != nil {
+		pr.ClosedBy = bbPr.ClosedBy.DisplayName
+	}
+
+	pr.CreatedOn, _ = time.Parse(bbTimeLayout, bbPr.CreatedOn)
+	pr.UpdatedOn, _ = time.Parse(bbTimeLayout, bbPr.UpdatedOn)
+	processReviewers(bbPr.Participants, &pr, c.userId)
+	return pr
+}
+
+func (c BitbucketClient) getPullRequests(repo string) ([]PullRequest, error) {
 	prs := make([]PullRequest, 0)
+	path := fmt.Sprintf("repositories/%s/pullrequests?state=OPEN&pagelen=50&fields=%s", repo, prFieldsStr)
+	if c.config.Mode == ModeReviewing {
+		// Only my pull requests and the ones I review, filtered by the API to keep whole workspaces fast.
+		path += "&q=" + url.QueryEscape(fmt.Sprintf("author.uuid=%q OR reviewers.uuid=%q", c.userUuid, c.userUuid))
+	}
+	resp, err := c.get(path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("%s: %s", repo, resp.Status)
+	}
+	var bbPrs bbPullRequestsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&bbPrs); err != nil {
+		return nil, fmt.Errorf("%s: %w", repo, err)
+	}
 
 	for _, bbPr := range bbPrs.Values {
-		pr := PullRequest{
-			Id:            fmt.Sprintf("%d", bbPr.Id),
-			Repo:          repo,
-			Title:         bbPr.Title,
-			Author:        bbPr.Author.DisplayName,
-			LastCommit:    bbPr.Source.Commit.Hash,
-			Branch:        bbPr.Source.Branch.Name,
-			TargetBranch:  bbPr.Destination.Branch.Name,
-			CommentsCount: bbPr.CommentCount,
-			Url:           bbPr.Links.Html.Href,
-			IsMine:        bbPr.Author.AccountId == c.userId,
-		}
+		prs = append(prs, c.toPullRequest(repo, bbPr))
+	}
+	return prs, nil
+}
 
-		pr.UpdatedOn, _ = time.Parse("2006-01-02T15:04:05.000000-07:00", bbPr.UpdatedOn)
-		processReviewers(bbPr.Participants, &pr, c.userId)
+// GetAllPullRequests skips the repositories which could not be loaded, e.g. because of missing permissions,
+// and reports them in RepositoryErrors. It fails altogether only if none of them could be loaded.
+func (c BitbucketClient) GetAllPullRequests() ([]PullRequest, error) {
+	repos, err := c.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	isSweep := false
+	if c.config.Mode == ModeReviewing {
+		repos, isSweep = c.involvedRepositories(repos)
+	}
+	repoPrs := make([][]PullRequest, len(repos))
+	errs := make([]error, len(repos))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxConcurrentRequests)
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(i int, repo string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			repoPrs[i], errs[i] = c.getPullRequests(repo)
+		}(i, repo)
+	}
+	wg.Wait()
 
-		if pr.IsMine || pr.AmIParticipating {
-			prs = append(prs, pr)
+	if isSweep {
+		involved := make([]string, 0)
+		for i, repo := range repos {
+			// The ones which failed are looked at again until the next sweep, as they may well have some.
+			if len(repoPrs[i]) > 0 || errs[i] != nil {
+				involved = append(involved, repo)
+			}
 		}
+		c.repoCache.set(involvedReposKey, involved)
 	}
-	return prs
-}
 
-func (c BitbucketClient) GetAllPullRequests() []PullRequest {
 	allPrs := make([]PullRequest, 0)
-	for _, repo := range c.config.Repositories {
-		repoPrs := c.getPullRequests(repo)
-		allPrs = append(allPrs, repoPrs...)
+	repoErrors := make(RepositoryErrors)
+	for i, repo := range repos {
+		if errs[i] != nil {
+			repoErrors[repo] = errs[i]
+			continue
+		}
+		allPrs = append(allPrs, repoPrs[i]...)
+	}
+	if len(repoErrors) > 0 && len(repoErrors) == len(repos) {
+		return nil, repoErrors
 	}
 	sort.Slice(allPrs, func(i, j int) bool {
 		return allPrs[i].UpdatedOn.After(allPrs[j].UpdatedOn)
 	})
-	return allPrs
+	if len(repoErrors) > 0 {
+		return allPrs, repoErrors
+	}
+	return allPrs, nil
+}
+
+func (c BitbucketClient) GetCommits(pr PullRequest, since string) ([]Commit, error) {
+	path := fmt.Sprintf("repositories/%s/pullrequests/%s/commits?pagelen=50&fields=%s", pr.Repo, pr.Id,
+		"values.hash,values.date,values.author.raw,values.author.user.display_name")
+	var bbCommits bbCommitsResponse
+	if err := c.getJSON(path, &bbCommits); err != nil {
+		return nil, err
+	}
+
+	commits := make([]Commit, 0)
+	for _, bbCommit := range bbCommits.Values {
+		// The pull request only knows the abbreviated hash of its last commit.
+		if since != "" && strings.HasPrefix(bbCommit.Hash, since) {
+			break
+		}
+		commit := Commit{Hash: bbCommit.Hash, Author: bbCommit.Author.Raw}
+		if bbCommit.Author.User != nil {
+			commit.Author = bbCommit.Author.User.DisplayName
+		}
+		commit.Date, _ = time.Parse(time.RFC3339, bbCommit.Date)
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+func (c BitbucketClient) GetComments(pr PullRequest, since time.Time) ([]Comment, error) {
+	query := url.QueryEscape(fmt.Sprintf("created_on > %s", since.UTC().Format(time.RFC3339)))
+	path := fmt.Sprintf("repositories/%s/pullrequests/%s/comments?pagelen=100&sort=-created_on&q=%s&fields=%s", pr.Repo, pr.Id, query,
+		"values.created_on,values.user.display_name,values.deleted")
+	var bbComments bbCommentsResponse
+	if err := c.getJSON(path, &bbComments); err != nil {
+		return nil, err
+	}
+
+	comments := make([]Comment, 0)
+	for _, bbComment := range bbComments.Values {
+		if bbComment.Deleted {
+			continue
+		}
+		comment := Comment{Author: bbComment.User.DisplayName}
+		comment.CreatedOn, _ = time.Parse(bbTimeLayout, bbComment.CreatedOn)
+		comments = append(comments, comment)
+	}
+	return comments, nil
+}
+
+func (c BitbucketClient) GetPullRequest(repo string, id string) (PullRequest, error) {
+	fields := strings.ReplaceAll(prFieldsStr, "values.", "") + ",description,state,closed_by.display_name"
+	var bbPr bbPullRequest
+	if err := c.getJSON(fmt.Sprintf("repositories/%s/pullrequests/%s?fields=%s", repo, id, fields), &bbPr); err != nil {
+		return PullRequest{}, err
+	}
+	return c.toPullRequest(repo, bbPr), nil
+}
+
+type bbMember struct {
+	User bbUser `json:"user"`
+}
+
+type bbMembersResponse struct {
+	Values []bbMember `json:"values"`
+	Next   string     `json:"next"`
+}
+
+func (c BitbucketClient) GetMembers(workspace string) ([]User, error) {
+	path := fmt.Sprintf("workspaces/%s/members?pagelen=100&fields=next,values.user.display_name,values.user.account_id,values.user.uuid", workspace)
+	users := make([]User, 0)
+	for path != "" {
+		var page bbMembersResponse
+		if err := c.getJSON(path, &page); err != nil {
+			return nil, err
+		}
+		for _, member := range page.Values {
+			users = append(users, member.User.toUser())
+		}
+		path = strings.TrimPrefix(page.Next, c.apiUrl)
+	}
+	return users, nil
+}
+
+type bbAccountRef struct {
+	Uuid string `json:"uuid"`
+}
+
+type bbReviewersUpdate struct {
+	Title     string         `json:"title"`
+	Reviewers []bbAccountRef `json:"reviewers"`
+}
+
+func (c BitbucketClient) SetReviewers(pr PullRequest, reviewers []User) (PullRequest, error) {
+	update := bbReviewersUpdate{Title: pr.Title, Reviewers: make([]bbAccountRef, 0, len(reviewers))}
+	for _, reviewer := range reviewers {
+		update.Reviewers = append(update.Reviewers, bbAccountRef{reviewer.Uuid})
+	}
+	var bbPr bbPullRequest
+	if err := c.putJSON(fmt.Sprintf("repositories/%s/pullrequests/%s", pr.Repo, pr.Id), update, &bbPr); err != nil {
+		return PullRequest{}, err
+	}
+	return c.toPullRequest(pr.Repo, bbPr), nil
+}
+
+type bbBranchesResponse struct {
+	Values []bbBranch `json:"values"`
+	Next   string     `json:"next"`
+}
+
+func (c BitbucketClient) GetBranches(repo string) ([]string, error) {
+	path := fmt.Sprintf("repositories/%s/refs/branches?pagelen=100&sort=-target.date&fields=next,values.name", repo)
+	branches := make([]string, 0)
+	for path != "" {
+		var page bbBranchesResponse
+		if err := c.getJSON(path, &page); err != nil {
+			return nil, err
+		}
+		for _, branch := range page.Values {
+			branches = append(branches, branch.Name)
+		}
+		path = strings.TrimPrefix(page.Next, c.apiUrl)
+	}
+	return branches, nil
+}
+
+type bbDestinationUpdate struct {
+	Branch bbBranch `json:"branch"`
+}
+
+type bbPullRequestUpdate struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Destination bbDestinationUpdate `json:"destination"`
+	Reviewers   []bbAccountRef      `json:"reviewers"`
+}
+
+// UpdatePullRequest sends the current reviewers along, so that they're kept as they are.
+func (c BitbucketClient) UpdatePullRequest(pr PullRequest, update PullRequestUpdate) (PullRequest, error) {
+	bbUpdate := bbPullRequestUpdate{
+		Title:       update.Title,
+		Description: update.Description,
+		Destination: bbDestinationUpdate{bbBranch{update.TargetBranch}},
+		Reviewers:   make([]bbAccountRef, 0, len(pr.Reviewers)),
+	}
+	for _, reviewer := range pr.Reviewers {
+		bbUpdate.Reviewers = append(bbUpdate.Reviewers, bbAccountRef{reviewer.Uuid})
+	}
+	var bbPr bbPullRequest
+	if err := c.putJSON(fmt.Sprintf("repositories/%s/pullrequests/%s", pr.Repo, pr.Id), bbUpdate, &bbPr); err != nil {
+		return PullRequest{}, err
+	}
+	return c.toPullRequest(pr.Repo, bbPr), nil
+}
+
+type bbDiffstat struct {
+	Status string `json:"status"`
+}
+
+type bbDiffstatResponse struct {
+	Values []bbDiffstat `json:"values"`
+	Next   string       `json:"next"`
+}
+
+// HasConflicts looks for files in the "merge conflict" status in the diffstat of the pull request.
+func (c BitbucketClient) HasConflicts(pr PullRequest) (bool, error) {
+	path := fmt.Sprintf("repositories/%s/pullrequests/%s/diffstat?pagelen=100&fields=next,values.status", pr.Repo, pr.Id)
+	for path != "" {
+		var page bbDiffstatResponse
+		if err := c.getJSON(path, &page); err != nil {
+			return false, err
+		}
+		for _, file := range page.Values {
+			if file.Status == "merge conflict" {
+				return true, nil
+			}
+		}
+		path = strings.TrimPrefix(page.Next, c.apiUrl)
+	}
+	return false, nil
 }
@@ -0,0 +1,223 @@
+package prs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newReplayClient(t *testing.T) BitbucketClient {
+	t.Helper()
+	config := AccountConfig{Username: "me", Password: "secret", Repositories: []string{"acme/api"}}
+	c, ok := NewBitbucketClient(config, &http.Client{Transport: ReplayTransport{Dir: "testdata"}})
+	if !ok {
+		t.Fatal("could not create the client from fixtures")
+	}
+	return c
+}
+
+func TestGetAllPullRequests(t *testing.T) {
+	c := newReplayClient(t)
+	prs, err := c.GetAllPullRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(prs) != 3 {
+		t.Fatalf("expected all 3 open pull requests, got %d", len(prs))
+	}
+
+	others := prs[0]
+	if others.Uid() != "acme/api/14" {
+		t.Errorf("expected the most recently updated pull request first, got %s", others.Uid())
+	}
+	if others.IsMine || others.AmIParticipating {
+		t.Errorf("expected a pull request where I don't participate: %+v", others)
+	}
+
+	reviewed := prs[1]
+	if reviewed.MyReview != Approved || !reviewed.AmIParticipating || reviewed.IsMine {
+		t.Errorf("unexpected review state: %+v", reviewed)
+	}
+	if reviewed.ReviewersCount != 2 || reviewed.ApprovedCount != 1 || reviewed.RequestedChangesCount != 1 {
+		t.Errorf("unexpected review counts: %+v", reviewed)
+	}
+	if len(reviewed.Approvers) != 1 || reviewed.Approvers[0] != "Jan Me" || len(reviewed.ChangesRequestedBy) != 1 || reviewed.ChangesRequestedBy[0] != "Bob" {
+		t.Errorf("unexpected reviewers: %v, %v", reviewed.Approvers, reviewed.ChangesRequestedBy)
+	}
+	if len(reviewed.ApprovedBy) != 1 || reviewed.ApprovedBy[0].Key() == "" {
+		t.Errorf("expected the approver with an account id, got %v", reviewed.ApprovedBy)
+	}
+	if reviewed.SourceRepo != "alice/api" || reviewed.Branch != "login" || reviewed.LastCommit != "abc123def456" {
+		t.Errorf("unexpected source: %+v", reviewed)
+	}
+	if want := time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC); !reviewed.CreatedOn.Equal(want) {
+		t.Errorf("expected CreatedOn %s, got %s", want, reviewed.CreatedOn)
+	}
+
+	mine := prs[2]
+	if !mine.IsMine || mine.MyReview != NoReview || !mine.IsDraft {
+		t.Errorf("unexpected state of my pull request: %+v", mine)
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte(`{"path": "` + r.URL.Path + `"}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recorder := &http.Client{Transport: RecordingTransport{Dir: dir}}
+	resp, err := recorder.Get(server.URL + "/2.0/things?page=2&fields=a,b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	replayer := &http.Client{Transport: ReplayTransport{Dir: dir}}
+	resp, err = replayer.Get("https://example.com/2.0/things?page=2&fields=c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusTeapot {
+		t.Errorf("expected the recorded status, got %d", resp.StatusCode)
+	}
+
+	if _, err := replayer.Get("https://example.com/2.0/things?page=3"); err == nil {
+		t.Error("expected an error for a request that was not recorded")
+	}
+}
+
+func TestRecordingFailsWhenFixtureCantBeWritten(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	req, _ := http.NewRequest("GET", server.URL+"/2.0/things", nil)
+	// A directory in place of the fixture can't be written over.
+	os.Mkdir(filepath.Join(dir, FixtureName(req)), 0700)
+	resp, err := RecordingTransport{Dir: dir}.RoundTrip(req)
+	if err == nil || resp != nil {
+		t.Errorf("expected only an error, got %v and %v", resp, err)
+	}
+}
+
+func TestGetCommitsAndComments(t *testing.T) {
+	c := newReplayClient(t)
+	pr := PullRequest{Repo: "acme/api", Id: "12"}
+
+	commits, err := c.GetCommits(pr, "abc123def456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 2 || commits[0].Author != "Bob" || commits[1].Author != "CI Bot <ci@example.com>" {
+		t.Errorf("expected the commits after abc123def456, got %+v", commits)
+	}
+
+	comments, err := c.GetComments(pr, time.Date(2022, 8, 3, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 || comments[0].Author != "Carol" {
+		t.Errorf("expected one comment by Carol, got %+v", comments)
+	}
+}
+
+func TestGetPullRequest(t *testing.T) {
+	c := newReplayClient(t)
+	pr, err := c.GetPullRequest("acme/api", "14")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Uid() != "acme/api/14" || pr.State != StateMerged || pr.ClosedBy != "Bob" {
+		t.Errorf("unexpected pull request: %+v", pr)
+	}
+	if pr.Description != "Moves the storage layer behind an interface." {
+		t.Errorf("unexpected description: %q", pr.Description)
+	}
+}
+
+func TestGetMembers(t *testing.T) {
+	c := newReplayClient(t)
+	members, err := c.GetMembers("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 3 || members[0].DisplayName != "Alice" || members[0].Uuid != "{a1}" {
+		t.Errorf("unexpected members: %+v", members)
+	}
+}
+
+func TestSetReviewers(t *testing.T) {
+	c := newReplayClient(t)
+	alice := User{AccountId: "alice-1", Uuid: "{a1}", DisplayName: "Alice"}
+	pr, err := c.SetReviewers(PullRequest{Repo: "acme/api", Id: "13", Title: "Bump dependencies"}, []User{alice})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pr.Reviewers) != 1 || pr.Reviewers[0] != alice || pr.ReviewersCount != 1 {
+		t.Errorf("unexpected reviewers: %+v", pr.Reviewers)
+	}
+
+	bob := User{AccountId: "bob-1", Uuid: "{b1}", DisplayName: "Bob"}
+	_, err = c.SetReviewers(PullRequest{Repo: "acme/api", Id: "14"}, []User{bob})
+	if err == nil || err.Error() != "Bob is the author and cannot be included as a reviewer." {
+		t.Errorf("expected the error message from the API, got %v", err)
+	}
+}
+
+func TestGetBranches(t *testing.T) {
+	c := newReplayClient(t)
+	branches, err := c.GetBranches("acme/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(branches) != 4 || branches[2] != "release/2.0" {
+		t.Errorf("unexpected branches: %v", branches)
+	}
+}
+
+func TestHasConflicts(t *testing.T) {
+	c := newReplayClient(t)
+	hasConflicts, err := c.HasConflicts(PullRequest{Repo: "acme/api", Id: "12"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasConflicts {
+		t.Error("expected a file in the merge conflict status to be found")
+	}
+}
+
+func TestUpdatePullRequest(t *testing.T) {
+	c := newReplayClient(t)
+	update := PullRequestUpdate{Title: "Add login endpoint", Description: "Fixes #42", TargetBranch: "release/2.0"}
+	pr, err := c.UpdatePullRequest(PullRequest{Repo: "acme/api", Id: "12"}, update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Title != update.Title || pr.Description != update.Description || pr.TargetBranch != update.TargetBranch {
+		t.Errorf("unexpected pull request: %+v", pr)
+	}
+}
+
+func TestGetAllPullRequestsSkipsFailedRepositories(t *testing.T) {
+	config := AccountConfig{Username: "me", Password: "secret", Repositories: []string{"acme/api", "acme/secret"}}
+	c, _ := NewBitbucketClient(config, &http.Client{Transport: ReplayTransport{Dir: "testdata"}})
+	prs, err := c.GetAllPullRequests()
+
+	failed, ok := err.(RepositoryErrors)
+	if !ok || len(failed) != 1 || failed["acme/secret"] == nil {
+		t.Fatalf("expected acme/secret to fail, got %v", err)
+	}
+	if len(prs) != 3 {
+		t.Errorf("expected the pull requests of acme/api, got %d", len(prs))
+	}
+}
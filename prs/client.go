@@ -1,5 +1,51 @@
 package prs
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
 type Client interface {
-	GetAllPullRequests() []PullRequest
+	// GetAllPullRequests returns the pull requests of the repositories which could be loaded
+	// along with RepositoryErrors naming the ones which couldn't.
+	GetAllPullRequests() ([]PullRequest, error)
+	// GetPullRequest returns a single pull request in any state, e.g. to find out how it was closed.
+	GetPullRequest(repo string, id string) (PullRequest, error)
+	// GetCommits returns the commits of the pull request made after the given one, newest first.
+	GetCommits(pr PullRequest, since string) ([]Commit, error)
+	// GetComments returns the comments on the pull request created after the given time.
+	GetComments(pr PullRequest, since time.Time) ([]Comment, error)
+	// GetMembers lists the users who can be added as reviewers in the workspace.
+	GetMembers(workspace string) ([]User, error)
+	// SetReviewers replaces the reviewers of the pull request and returns it updated.
+	SetReviewers(pr PullRequest, reviewers []User) (PullRequest, error)
+	// GetBranches lists the branches of the repository, the most recently updated first.
+	GetBranches(repo string) ([]string, error)
+	// UpdatePullRequest changes the title, description and target branch of the pull request and returns it updated.
+	UpdatePullRequest(pr PullRequest, update PullRequestUpdate) (PullRequest, error)
+	// HasConflicts tells whether merging the pull request would result in conflicts.
+	HasConflicts(pr PullRequest) (bool, error)
+}
+
+// RepositoryErrors maps the repositories whose pull requests could not be loaded to the reason.
+type RepositoryErrors map[string]error
+
+// Repos lists the failed repositories in alphabetical order.
+func (e RepositoryErrors) Repos() []string {
+	repos := make([]string, 0, len(e))
+	for repo := range e {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	return repos
+}
+
+func (e RepositoryErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, repo := range e.Repos() {
+		messages = append(messages, fmt.Sprintf("%s: %s", repo, e[repo]))
+	}
+	return strings.Join(messages, ", ")
 }
@@ -1,7 +1,43 @@
 package prs
 
+import "fmt"
+
 type AccountConfig struct {
-	Username     string
-	Password     string
-	Repositories []string
+	Username        string
+	Password        string
+	PasswordEnv     string
+	PasswordCommand string
+	KeyringService  string
+	Repositories    []string
+	Mode            string
+	Workspaces      []string
+	// RepositoryCachePath is where the expanded repository patterns are cached, set by bb.
+	RepositoryCachePath string `toml:"-"`
+}
+
+func (c *AccountConfig) Validate() error {
+	switch c.Mode {
+	case "":
+		c.Mode = ModeRepositories
+	case ModeRepositories:
+	case ModeReviewing:
+		if len(c.Workspaces) == 0 {
+			return fmt.Errorf("the %s mode needs at least one workspace in Workspaces", ModeReviewing)
+		}
+	default:
+		return fmt.Errorf("invalid Mode %q", c.Mode)
+	}
+	return nil
+}
+
+// RepositoryEntries returns the configured repositories, with all repositories
+// of the configured workspaces in the reviewing mode.
+func (c AccountConfig) RepositoryEntries() []string {
+	entries := append([]string{}, c.Repositories...)
+	if c.Mode == ModeReviewing {
+		for _, workspace := range c.Workspaces {
+			entries = append(entries, workspace+"/*")
+		}
+	}
+	return entries
 }
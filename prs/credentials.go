@@ -0,0 +1,62 @@
+package prs
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ResolveCredentials fills in the password from the configured source.
+// Sources are tried in order: an environment variable, an external command
+// and the Secret Service keyring. A plain Password is used when none is set.
+func (c *AccountConfig) ResolveCredentials() error {
+	switch {
+	case c.PasswordEnv != "":
+		password, ok := os.LookupEnv(c.PasswordEnv)
+		if !ok || password == "" {
+			return fmt.Errorf("environment variable %s is not set", c.PasswordEnv)
+		}
+		c.Password = password
+
+	case c.PasswordCommand != "":
+		password, err := runCredentialCommand("sh", "-c", c.PasswordCommand)
+		if err != nil {
+			return fmt.Errorf("password command %q failed: %w", c.PasswordCommand, err)
+		}
+		c.Password = password
+
+	case c.KeyringService != "":
+		password, err := runCredentialCommand("secret-tool", "lookup", "service", c.KeyringService, "username", c.Username)
+		if err != nil {
+			return fmt.Errorf("keyring lookup for service %q and user %q failed: %w", c.KeyringService, c.Username, err)
+		}
+		c.Password = password
+	}
+
+	if c.Password == "" {
+		return fmt.Errorf("no password configured for %q", c.Username)
+	}
+	return nil
+}
+
+func runCredentialCommand(name string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	// Only the first line counts, like in pass and other password managers.
+	password := strings.SplitN(stdout.String(), "\n", 2)[0]
+	password = strings.TrimRight(password, "\r")
+	if password == "" {
+		return "", fmt.Errorf("empty output")
+	}
+	return password, nil
+}
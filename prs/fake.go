@@ -0,0 +1,252 @@
+package prs
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FakeClient is an in-memory Client for tests.
+type FakeClient struct {
+	mu        sync.Mutex
+	prs       []PullRequest
+	commits   map[Uid][]Commit
+	comments  map[Uid][]Comment
+	closed    map[Uid]PullRequest
+	members   map[string][]User
+	branches  map[string][]string
+	conflicts map[Uid]bool
+	err       error
+	repoErrs  RepositoryErrors
+	calls     int
+}
+
+func NewFakeClient(prs ...PullRequest) *FakeClient {
+	return &FakeClient{
+		prs:       prs,
+		commits:   make(map[Uid][]Commit),
+		comments:  make(map[Uid][]Comment),
+		closed:    make(map[Uid]PullRequest),
+		members:   make(map[string][]User),
+		branches:  make(map[string][]string),
+		conflicts: make(map[Uid]bool),
+		repoErrs:  make(RepositoryErrors),
+	}
+}
+
+func (c *FakeClient) SetMembers(workspace string, members ...User) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.members[workspace] = members
+}
+
+func (c *FakeClient) SetBranches(repo string, branches ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.branches[repo] = branches
+}
+
+func (c *FakeClient) SetConflicts(uid Uid, hasConflicts bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conflicts[uid] = hasConflicts
+}
+
+// Close removes the pull request from the open ones, GetPullRequest then returns it in the given state.
+func (c *FakeClient) Close(uid Uid, state State, closedBy string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	open := make([]PullRequest, 0, len(c.prs))
+	for _, pr := range c.prs {
+		if pr.Uid() == uid {
+			pr.State = state
+			pr.ClosedBy = closedBy
+			c.closed[uid] = pr
+		} else {
+			open = append(open, pr)
+		}
+	}
+	c.prs = open
+}
+
+// SetCommits replaces the commits of the pull request, newest first.
+func (c *FakeClient) SetCommits(uid Uid, commits ...Commit) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.commits[uid] = commits
+}
+
+func (c *FakeClient) SetComments(uid Uid, comments ...Comment) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.comments[uid] = comments
+}
+
+// SetPullRequests replaces the pull requests returned by the next calls.
+func (c *FakeClient) SetPullRequests(prs ...PullRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prs = prs
+}
+
+// SetError makes the next calls fail with err, or succeed again if err is nil.
+func (c *FakeClient) SetError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+// SetRepositoryError leaves the pull requests of the repository out of GetAllPullRequests,
+// or includes them again if err is nil.
+func (c *FakeClient) SetRepositoryError(repo string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		delete(c.repoErrs, repo)
+	} else {
+		c.repoErrs[repo] = err
+	}
+}
+
+func (c *FakeClient) Calls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+func (c *FakeClient) GetAllPullRequests() ([]PullRequest, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	if len(c.repoErrs) == 0 {
+		prs := make([]PullRequest, len(c.prs))
+		copy(prs, c.prs)
+		return prs, nil
+	}
+	prs := make([]PullRequest, 0, len(c.prs))
+	repoErrs := make(RepositoryErrors)
+	for _, pr := range c.prs {
+		if err, ok := c.repoErrs[pr.Repo]; ok {
+			repoErrs[pr.Repo] = err
+		} else {
+			prs = append(prs, pr)
+		}
+	}
+	return prs, repoErrs
+}
+
+func (c *FakeClient) GetPullRequest(repo string, id string) (PullRequest, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return PullRequest{}, c.err
+	}
+	uid := repo + "/" + id
+	if pr, ok := c.closed[uid]; ok {
+		return pr, nil
+	}
+	for _, pr := range c.prs {
+		if pr.Uid() == uid {
+			pr.State = StateOpen
+			return pr, nil
+		}
+	}
+	return PullRequest{}, fmt.Errorf("pull request %s not found", uid)
+}
+
+func (c *FakeClient) GetCommits(pr PullRequest, since string) ([]Commit, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	commits := make([]Commit, 0)
+	for _, commit := range c.commits[pr.Uid()] {
+		if commit.Hash == since {
+			break
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+func (c *FakeClient) GetComments(pr PullRequest, since time.Time) ([]Comment, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	comments := make([]Comment, 0)
+	for _, comment := range c.comments[pr.Uid()] {
+		if comment.CreatedOn.After(since) {
+			comments = append(comments, comment)
+		}
+	}
+	return comments, nil
+}
+
+func (c *FakeClient) GetMembers(workspace string) ([]User, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.members[workspace], nil
+}
+
+// SetReviewers replaces the reviewers and updates their count.
+func (c *FakeClient) SetReviewers(pr PullRequest, reviewers []User) (PullRequest, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return PullRequest{}, c.err
+	}
+	for i, openPr := range c.prs {
+		if openPr.Uid() == pr.Uid() {
+			openPr.Reviewers = reviewers
+			openPr.ReviewersCount = len(reviewers)
+			c.prs[i] = openPr
+			return openPr, nil
+		}
+	}
+	return PullRequest{}, fmt.Errorf("pull request %s not found", pr.Uid())
+}
+
+func (c *FakeClient) GetBranches(repo string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.branches[repo], nil
+}
+
+func (c *FakeClient) UpdatePullRequest(pr PullRequest, update PullRequestUpdate) (PullRequest, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return PullRequest{}, c.err
+	}
+	for i, openPr := range c.prs {
+		if openPr.Uid() == pr.Uid() {
+			openPr.Title = update.Title
+			openPr.Description = update.Description
+			openPr.TargetBranch = update.TargetBranch
+			c.prs[i] = openPr
+			return openPr, nil
+		}
+	}
+	return PullRequest{}, fmt.Errorf("pull request %s not found", pr.Uid())
+}
+
+func (c *FakeClient) HasConflicts(pr PullRequest) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return false, c.err
+	}
+	return c.conflicts[pr.Uid()], nil
+}
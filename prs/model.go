@@ -8,27 +8,84 @@ import (
 type Review int
 
 const (
-	Approved Review = 1
+	NoReview Review = iota
+	Approved
 	RequestedChanges
 )
 
+// ReviewUnknown is a review saved before NoReview was added, when Approved and RequestedChanges were both 1.
+const ReviewUnknown Review = -1
+
+type State string
+
+const (
+	StateOpen       State = "OPEN"
+	StateMerged     State = "MERGED"
+	StateDeclined   State = "DECLINED"
+	StateSuperseded State = "SUPERSEDED"
+)
+
 type PullRequest struct {
 	Id                    string
 	Repo                  string
+	SourceRepo            string
 	Title                 string
+	Description           string
 	Author                string
 	LastCommit            string
 	Branch                string
 	TargetBranch          string
 	IsMine                bool
 	AmIParticipating      bool
+	CreatedOn             time.Time
 	UpdatedOn             time.Time
 	CommentsCount         int
 	ReviewersCount        int
 	ApprovedCount         int
 	RequestedChangesCount int
 	MyReview              Review
+	IsDraft               bool
+	Reviewers             []User
+	Approvers             []string
+	ApprovedBy            []User
+	ChangesRequestedBy    []string
+	State                 State
+	ClosedBy              string
 	Url                   string
+	// HasConflicts is only checked when a filter asks for it, it is not part of the pull requests list.
+	HasConflicts bool `json:"-"`
+}
+
+type User struct {
+	AccountId   string
+	Uuid        string
+	DisplayName string
+}
+
+// Key identifies the user across pull requests, unlike the display name, which can change and needn't be unique.
+func (u User) Key() string {
+	if u.AccountId != "" {
+		return u.AccountId
+	}
+	return u.Uuid
+}
+
+// PullRequestUpdate holds the fields of a pull request which can be edited from bb.
+type PullRequestUpdate struct {
+	Title        string
+	Description  string
+	TargetBranch string
+}
+
+type Commit struct {
+	Hash   string
+	Author string
+	Date   time.Time
+}
+
+type Comment struct {
+	Author    string
+	CreatedOn time.Time
 }
 
 type Uid = string
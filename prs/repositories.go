@@ -0,0 +1,205 @@
+package prs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ModeRepositories = "repositories"
+	ModeReviewing    = "reviewing"
+)
+
+// repositoriesTTL is how long the expansion of a repository pattern is reused before asking the API again.
+const repositoriesTTL = time.Hour
+
+// involvedReposKey caches the repositories with my pull requests in the reviewing mode, next to the patterns.
+// It is not a pattern, so it never collides with a configured entry.
+const involvedReposKey = "reviewing:involved"
+
+// IsRepositoryPattern tells whether the entry of Repositories is expanded via the API,
+// i.e. it's "workspace/*", "project:workspace/KEY" or "project:KEY".
+func IsRepositoryPattern(entry string) bool {
+	return strings.HasSuffix(entry, "/*") || strings.HasPrefix(entry, "project:")
+}
+
+type expandedPattern struct {
+	Repos     []string
+	FetchedAt time.Time
+}
+
+// repositoryCache keeps the expanded repository patterns in memory and in a file,
+// so that bb starts without waiting for the API and keeps working offline.
+type repositoryCache struct {
+	mu       sync.Mutex
+	path     string
+	Patterns map[string]expandedPattern
+}
+
+func newRepositoryCache(path string) *repositoryCache {
+	cache := &repositoryCache{path: path, Patterns: make(map[string]expandedPattern)}
+	if path == "" {
+		return cache
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, cache)
+	}
+	if cache.Patterns == nil {
+		cache.Patterns = make(map[string]expandedPattern)
+	}
+	return cache
+}
+
+func (cache *repositoryCache) get(pattern string) (expandedPattern, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	expanded, ok := cache.Patterns[pattern]
+	return expanded, ok
+}
+
+func (cache *repositoryCache) set(pattern string, repos []string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.Patterns[pattern] = expandedPattern{repos, time.Now()}
+	if cache.path == "" {
+		return
+	}
+	if data, err := json.MarshalIndent(cache, "", " "); err == nil {
+		os.MkdirAll(filepath.Dir(cache.path), 0700)
+		os.WriteFile(cache.path, data, 0600)
+	}
+}
+
+type bbRepositoriesResponse struct {
+	Values []bbRepository `json:"values"`
+	Next   string         `json:"next"`
+}
+
+type bbWorkspacePermission struct {
+	Workspace struct {
+		Slug string `json:"slug"`
+	} `json:"workspace"`
+}
+
+type bbWorkspacePermissionsResponse struct {
+	Values []bbWorkspacePermission `json:"values"`
+}
+
+// listRepositories follows the pagination of the workspace's repositories, optionally filtered with a query.
+func (c BitbucketClient) listRepositories(workspace string, query string) ([]string, error) {
+	path := fmt.Sprintf("repositories/%s?pagelen=100&fields=next,values.full_name", workspace)
+	if query != "" {
+		path += "&q=" + url.QueryEscape(query)
+	}
+	repos := make([]string, 0)
+	for path != "" {
+		var page bbRepositoriesResponse
+		if err := c.getJSON(path, &page); err != nil {
+			return nil, err
+		}
+		for _, repo := range page.Values {
+			repos = append(repos, repo.FullName)
+		}
+		path = strings.TrimPrefix(page.Next, c.apiUrl)
+	}
+	return repos, nil
+}
+
+func (c BitbucketClient) myWorkspaces() ([]string, error) {
+	var permissions bbWorkspacePermissionsResponse
+	if err := c.getJSON("user/permissions/workspaces?pagelen=100&fields=values.workspace.slug", &permissions); err != nil {
+		return nil, err
+	}
+	workspaces := make([]string, 0, len(permissions.Values))
+	for _, permission := range permissions.Values {
+		workspaces = append(workspaces, permission.Workspace.Slug)
+	}
+	return workspaces, nil
+}
+
+func (c BitbucketClient) expandPattern(pattern string) ([]string, error) {
+	if workspace := strings.TrimSuffix(pattern, "/*"); workspace != pattern {
+		return c.listRepositories(workspace, "")
+	}
+
+	project := strings.TrimPrefix(pattern, "project:")
+	parts := strings.SplitN(project, "/", 2)
+	if len(parts) == 2 {
+		return c.listRepositories(parts[0], fmt.Sprintf("project.key=%q", parts[1]))
+	}
+	workspaces, err := c.myWorkspaces()
+	if err != nil {
+		return nil, err
+	}
+	repos := make([]string, 0)
+	for _, workspace := range workspaces {
+		workspaceRepos, err := c.listRepositories(workspace, fmt.Sprintf("project.key=%q", project))
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, workspaceRepos...)
+	}
+	return repos, nil
+}
+
+// Repositories expands the repository patterns, reusing the cached expansion for an hour.
+// When the API can't be reached, an outdated expansion is better than none.
+func (c BitbucketClient) Repositories() ([]string, error) {
+	seen := make(map[string]bool)
+	repos := make([]string, 0)
+	add := func(repo string) {
+		if !seen[strings.ToLower(repo)] {
+			seen[strings.ToLower(repo)] = true
+			repos = append(repos, repo)
+		}
+	}
+
+	for _, entry := range c.config.RepositoryEntries() {
+		if !IsRepositoryPattern(entry) {
+			add(entry)
+			continue
+		}
+		expanded, isCached := c.repoCache.get(entry)
+		if !isCached || time.Since(expanded.FetchedAt) > repositoriesTTL {
+			fetched, err := c.expandPattern(entry)
+			if err != nil && !isCached {
+				return nil, fmt.Errorf("%s: %w", entry, err)
+			}
+			if err == nil {
+				c.repoCache.set(entry, fetched)
+				expanded.Repos = fetched
+			}
+		}
+		for _, repo := range expanded.Repos {
+			add(repo)
+		}
+	}
+	return repos, nil
+}
+
+// involvedRepositories narrows the repositories of the reviewing mode down to the configured ones and the ones
+// which had my pull requests the last time all of them were looked at. That happens once an hour, so that
+// the auto-update doesn't send a request for every repository of the workspaces. It tells when all are due.
+func (c BitbucketClient) involvedRepositories(repos []string) ([]string, bool) {
+	involved, ok := c.repoCache.get(involvedReposKey)
+	if !ok || time.Since(involved.FetchedAt) > repositoriesTTL {
+		return repos, true
+	}
+	keep := make(map[string]bool)
+	for _, repo := range append(involved.Repos, c.config.Repositories...) {
+		keep[strings.ToLower(repo)] = true
+	}
+	narrowed := make([]string, 0, len(involved.Repos))
+	for _, repo := range repos {
+		if keep[strings.ToLower(repo)] {
+			narrowed = append(narrowed, repo)
+		}
+	}
+	return narrowed, false
+}
@@ -0,0 +1,105 @@
+package prs
+
+import (
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRepositories(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "repositories.json")
+	config := AccountConfig{
+		Repositories:        []string{"acme/*", "project:CORE", "acme/api"},
+		RepositoryCachePath: cachePath,
+	}
+	c, _ := NewBitbucketClient(config, &http.Client{Transport: ReplayTransport{Dir: "testdata"}})
+
+	want := []string{"acme/api", "acme/web", "beta/core"}
+	repos, err := c.Repositories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(repos, want) {
+		t.Errorf("expected %v, got %v", want, repos)
+	}
+
+	// Without network access, even an outdated cached expansion is used.
+	offline, _ := NewBitbucketClient(config, &http.Client{Transport: ReplayTransport{Dir: t.TempDir()}})
+	for pattern, expanded := range offline.repoCache.Patterns {
+		expanded.FetchedAt = time.Now().Add(-2 * repositoriesTTL)
+		offline.repoCache.Patterns[pattern] = expanded
+	}
+	repos, err = offline.Repositories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(repos, want) {
+		t.Errorf("expected %v from the cache, got %v", want, repos)
+	}
+
+	config.RepositoryCachePath = ""
+	uncached, _ := NewBitbucketClient(config, &http.Client{Transport: ReplayTransport{Dir: t.TempDir()}})
+	if _, err := uncached.Repositories(); err == nil {
+		t.Error("expected an error without network access and cache")
+	}
+}
+
+func TestReviewingMode(t *testing.T) {
+	config := AccountConfig{
+		Mode:                ModeReviewing,
+		Workspaces:          []string{"acme"},
+		RepositoryCachePath: filepath.Join(t.TempDir(), "repositories.json"),
+	}
+	// The fixtures are recorded with q=author.uuid="{me}" OR reviewers.uuid="{me}",
+	// the pull requests of others in acme/api are filtered out by the API.
+	c, _ := NewBitbucketClient(config, &http.Client{Transport: ReplayTransport{Dir: "testdata"}})
+	prs, err := c.GetAllPullRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	uids := make([]string, len(prs))
+	for i, pr := range prs {
+		uids[i] = pr.Uid()
+	}
+	if want := []string{"acme/api/12", "acme/api/13", "acme/web/3"}; !reflect.DeepEqual(uids, want) {
+		t.Errorf("expected %v, got %v", want, uids)
+	}
+}
+
+func TestReviewingModeSkipsUninvolvedRepositories(t *testing.T) {
+	config := AccountConfig{
+		Mode:                ModeReviewing,
+		Workspaces:          []string{"acme"},
+		RepositoryCachePath: filepath.Join(t.TempDir(), "repositories.json"),
+	}
+	c, _ := NewBitbucketClient(config, &http.Client{Transport: ReplayTransport{Dir: "testdata"}})
+	if _, err := c.GetAllPullRequests(); err != nil {
+		t.Fatal(err)
+	}
+	if involved, _ := c.repoCache.get(involvedReposKey); !reflect.DeepEqual(involved.Repos, []string{"acme/api", "acme/web"}) {
+		t.Errorf("expected both repositories to be involved, got %v", involved.Repos)
+	}
+
+	// acme/web is not asked for until the next sweep, as it had no pull requests of mine.
+	c.repoCache.set(involvedReposKey, []string{"acme/api"})
+	prs, err := c.GetAllPullRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pr := range prs {
+		if pr.Repo != "acme/api" {
+			t.Errorf("expected only the pull requests of acme/api, got %s", pr.Uid())
+		}
+	}
+}
+
+func TestValidateMode(t *testing.T) {
+	if err := (&AccountConfig{Mode: ModeReviewing}).Validate(); err == nil {
+		t.Error("expected an error for the reviewing mode without workspaces")
+	}
+	if err := (&AccountConfig{Mode: "everything"}).Validate(); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/hejmsdz/bb/prs"
+)
+
+const stateVersion = 3
+
+// stateFile is the envelope of state.json. State holds the exported fields of rootModel.
+type stateFile struct {
+	Version int
+	State   json.RawMessage
+}
+
+var errStateTooNew = errors.New("the state file was saved by a newer version of bb")
+
+// stateMigrations upgrade the state from the version given as the key to the next one.
+var stateMigrations = map[int]func(json.RawMessage) (json.RawMessage, error){
+	// Version 1 files were the bare model, without the envelope. Their reviews could be either approvals
+	// or change requests, as both were saved as 1.
+	1: migrateLegacyReviews,
+	// Version 2 files kept the approvals by the display name of the approver.
+	2: migrateApproverKeys,
+}
+
+type legacyPr map[string]json.RawMessage
+
+func markLegacyReview(pr legacyPr) {
+	if string(pr["MyReview"]) == "1" {
+		pr["MyReview"] = json.RawMessage(fmt.Sprint(int(prs.ReviewUnknown)))
+	}
+}
+
+// migrateLegacyReviews marks the ambiguous reviews in the snapshots of pull requests as unknown.
+func migrateLegacyReviews(state json.RawMessage) (json.RawMessage, error) {
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(state, &root); err != nil {
+		return nil, err
+	}
+
+	if raw, ok := root["WhatChanged"]; ok {
+		var whatChanged map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &whatChanged); err != nil {
+			return nil, err
+		}
+		var prevPrs map[string]legacyPr
+		if err := json.Unmarshal(whatChanged["PrevPrs"], &prevPrs); err == nil && prevPrs != nil {
+			for _, pr := range prevPrs {
+				markLegacyReview(pr)
+			}
+			whatChanged["PrevPrs"], _ = json.Marshal(prevPrs)
+			root["WhatChanged"], _ = json.Marshal(whatChanged)
+		}
+	}
+
+	if raw, ok := root["Prs"]; ok {
+		var prsModel map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &prsModel); err != nil {
+			return nil, err
+		}
+		var prList []legacyPr
+		if err := json.Unmarshal(prsModel["Prs"], &prList); err == nil && prList != nil {
+			for _, pr := range prList {
+				markLegacyReview(pr)
+			}
+			prsModel["Prs"], _ = json.Marshal(prList)
+			root["Prs"], _ = json.Marshal(prsModel)
+		}
+	}
+	return json.Marshal(root)
+}
+
+// savedReviewers is the part of a saved pull request needed to find its approvers.
+type savedReviewers struct {
+	Id        string
+	Repo      string
+	Reviewers []prs.User
+}
+
+// migrateApproverKeys finds the approvers among the reviewers of the saved pull requests by their display name.
+// The approvals of users who can't be found are dropped, they're taken as up to date on the next load.
+func migrateApproverKeys(state json.RawMessage) (json.RawMessage, error) {
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(state, &root); err != nil {
+		return nil, err
+	}
+	raw, ok := root["Approvals"]
+	if !ok {
+		return state, nil
+	}
+	var approvals map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &approvals); err != nil {
+		return nil, err
+	}
+	var commits map[prs.Uid]map[string]string
+	if err := json.Unmarshal(approvals["Commits"], &commits); err != nil || commits == nil {
+		return state, nil
+	}
+
+	var prsModel struct {
+		Prs []savedReviewers
+	}
+	json.Unmarshal(root["Prs"], &prsModel)
+	reviewers := make(map[prs.Uid][]prs.User)
+	for _, pr := range prsModel.Prs {
+		reviewers[pr.Repo+"/"+pr.Id] = pr.Reviewers
+	}
+
+	for uid, byName := range commits {
+		byKey := make(map[string]string)
+		for name, commit := range byName {
+			for _, reviewer := range reviewers[uid] {
+				if reviewer.DisplayName == name && reviewer.Key() != "" {
+					byKey[reviewer.Key()] = commit
+				}
+			}
+		}
+		commits[uid] = byKey
+	}
+	approvals["Commits"], _ = json.Marshal(commits)
+	root["Approvals"], _ = json.Marshal(approvals)
+	return json.Marshal(root)
+}
+
+func decodeState(data []byte) (json.RawMessage, error) {
+	var file stateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Version == 0 {
+		file = stateFile{Version: 1, State: data}
+	}
+	if file.Version > stateVersion {
+		return nil, errStateTooNew
+	}
+
+	for file.Version < stateVersion {
+		migrate := stateMigrations[file.Version]
+		state, err := migrate(file.State)
+		if err != nil {
+			return nil, fmt.Errorf("migrating state from version %d: %w", file.Version, err)
+		}
+		file = stateFile{Version: file.Version + 1, State: state}
+	}
+	return file.State, nil
+}
+
+func (m *rootModel) loadFrom(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	state, err := decodeState(data)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(state, m); err != nil {
+		return err
+	}
+	// Only the text of the query is saved, a query which no longer parses is dropped.
+	if _, err := m.Query.SetQuery(m.Query.Query); err != nil {
+		m.Query.Query = ""
+	}
+	return nil
+}
+
+// load restores the state saved by dump, falling back to the backup when the state file is missing or damaged.
+func (m *rootModel) load() error {
+	err := m.loadFrom(stateFilePath)
+	if err == nil || errors.Is(err, errStateTooNew) {
+		return err
+	}
+	if backupErr := m.loadFrom(stateFilePath + ".bak"); backupErr == nil {
+		return nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// pruneClosedPrs forgets pull requests which are no longer open, but only when the list is fresh.
+func (m rootModel) pruneClosedPrs() {
+	if !m.Prs.IsLoaded() || m.Prs.IsOffline() {
+		return
+	}
+	open := make(map[prs.Uid]bool)
+	for _, pr := range m.Prs.Prs {
+		open[pr.Uid()] = true
+	}
+	m.Ignores.Prune(open)
+	m.WhatChanged.Prune(open)
+	m.Watch.Prune(open)
+	m.Approvals.Prune(open)
+}
+
+func (m rootModel) dump() error {
+	m.pruneClosedPrs()
+	state, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(stateFile{Version: stateVersion, State: state}, "", " ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(stateFilePath, data)
+}
+
+// writeFileAtomic replaces the file so that it's never left half-written, keeping the previous version as a backup.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(path, path+".bak"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
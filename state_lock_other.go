@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package main
+
+// lockStateFile is a no-op on platforms without flock.
+func lockStateFile(path string) (unlock func(), err error) {
+	return func() {}, nil
+}
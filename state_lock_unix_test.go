@@ -0,0 +1,26 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLockStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	unlock, err := lockStateFile(path)
+	if err != nil {
+		t.Fatalf("lockStateFile: %v", err)
+	}
+	if _, err := lockStateFile(path); err == nil {
+		t.Error("expected the second lock to fail")
+	}
+	unlock()
+	unlock, err = lockStateFile(path)
+	if err != nil {
+		t.Fatalf("expected the lock to be free after unlocking: %v", err)
+	}
+	unlock()
+}
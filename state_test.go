@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hejmsdz/bb/prs"
+)
+
+func newStateModel(t *testing.T) rootModel {
+	t.Helper()
+	m, err := newRootModel(testConfig(), prs.NewFakeClient())
+	if err != nil {
+		t.Fatalf("newRootModel: %v", err)
+	}
+	return m
+}
+
+func TestLoadMigratesUnversionedState(t *testing.T) {
+	stateFilePath = filepath.Join(t.TempDir(), "state.json")
+	legacy := `{"Ignores": {"ShowIgnored": true, "IgnoredPrs": {"acme/api/1": "2022-01-01T00:00:00Z"}}}`
+	os.WriteFile(stateFilePath, []byte(legacy), 0600)
+
+	m := newStateModel(t)
+	if err := m.load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !m.Ignores.ShowIgnored || len(m.Ignores.IgnoredPrs) != 1 {
+		t.Errorf("ignores were not migrated: %+v", m.Ignores)
+	}
+}
+
+func TestLoadMarksLegacyReviewsUnknown(t *testing.T) {
+	stateFilePath = filepath.Join(t.TempDir(), "state.json")
+	legacy := `{"WhatChanged": {"PrevPrs": {"acme/api/1": {"Id": "1", "MyReview": 1}, "acme/api/2": {"Id": "2", "MyReview": 0}}}}`
+	os.WriteFile(stateFilePath, []byte(legacy), 0600)
+
+	m := newStateModel(t)
+	if err := m.load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if review := m.WhatChanged.PrevPrs["acme/api/1"].MyReview; review != prs.ReviewUnknown {
+		t.Errorf("expected the ambiguous review to be unknown, got %d", review)
+	}
+	if review := m.WhatChanged.PrevPrs["acme/api/2"].MyReview; review != prs.NoReview {
+		t.Errorf("expected no review, got %d", review)
+	}
+}
+
+func TestLoadKeysApprovalsByAccount(t *testing.T) {
+	stateFilePath = filepath.Join(t.TempDir(), "state.json")
+	saved := `{"Version": 2, "State": {
+		"Prs": {"Prs": [{"Id": "1", "Repo": "acme/api", "Reviewers": [{"AccountId": "alice-1", "DisplayName": "Alice"}]}]},
+		"Approvals": {"Commits": {"acme/api/1": {"Alice": "aaa", "Bob": "aaa"}}}
+	}}`
+	os.WriteFile(stateFilePath, []byte(saved), 0600)
+
+	m := newStateModel(t)
+	if err := m.load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	commits := m.Approvals.Commits["acme/api/1"]
+	if len(commits) != 1 || commits["alice-1"] != "aaa" {
+		t.Errorf("expected only the approval of the known reviewer by account id, got %v", commits)
+	}
+}
+
+func TestLoadRejectsNewerState(t *testing.T) {
+	stateFilePath = filepath.Join(t.TempDir(), "state.json")
+	os.WriteFile(stateFilePath, []byte(`{"Version": 99, "State": {}}`), 0600)
+
+	m := newStateModel(t)
+	if err := m.load(); !errors.Is(err, errStateTooNew) {
+		t.Errorf("expected errStateTooNew, got %v", err)
+	}
+}
+
+func TestDumpKeepsBackup(t *testing.T) {
+	stateFilePath = filepath.Join(t.TempDir(), "state.json")
+	m := newStateModel(t)
+	m.QuickFilters.ShowMineOnly = true
+	if err := m.dump(); err != nil {
+		t.Fatalf("dump: %v", err)
+	}
+	m.QuickFilters.ShowMineOnly = false
+	if err := m.dump(); err != nil {
+		t.Fatalf("dump: %v", err)
+	}
+
+	// A crash could leave the state file damaged, the backup is used instead.
+	os.WriteFile(stateFilePath, []byte(`{"Version": 2, "Sta`), 0600)
+	loaded := newStateModel(t)
+	if err := loaded.load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !loaded.QuickFilters.ShowMineOnly {
+		t.Error("expected the state to be restored from the backup")
+	}
+}
+
+func TestDumpPrunesClosedPrs(t *testing.T) {
+	client := prs.NewFakeClient(samplePrs()...)
+	h := newHarness(t, client, testConfig())
+	h.press("i")
+	h.model.Ignores.IgnoredPrs["acme/api/99"] = time.Now()
+
+	h = h.restart(client, testConfig())
+	if _, ok := h.model.Ignores.IgnoredPrs["acme/api/99"]; ok {
+		t.Error("expected the closed pull request to be pruned")
+	}
+	if len(h.model.Ignores.IgnoredPrs) != 1 {
+		t.Errorf("expected the open pull request to stay ignored, got %v", h.model.Ignores.IgnoredPrs)
+	}
+	if len(h.model.WhatChanged.PrevPrs) != 2 {
+		t.Errorf("expected snapshots of the open pull requests, got %d", len(h.model.WhatChanged.PrevPrs))
+	}
+
+	h.model.Approvals.Commits["acme/api/99"] = map[string]string{"alice-1": "aaa"}
+	h.model.Approvals.MyCommits["acme/api/99"] = "aaa"
+	h.model.pruneClosedPrs()
+	if _, ok := h.model.Approvals.Commits["acme/api/99"]; ok {
+		t.Error("expected the approvals of the closed pull request to be pruned")
+	}
+	if _, ok := h.model.Approvals.MyCommits["acme/api/99"]; ok {
+		t.Error("expected my approval of the closed pull request to be pruned")
+	}
+	if _, ok := h.model.Approvals.Commits["acme/api/1"]; !ok {
+		t.Error("expected the approvals of the open pull request to be kept")
+	}
+}
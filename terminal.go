@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hejmsdz/bb/model"
+	"github.com/hejmsdz/bb/prs"
+)
+
+type msgOpenInTerminal struct {
+	dir     string
+	command []string
+}
+
+// PreparePrDir makes the pull request branch available locally, either in its worktree
+// or by checking it out in the local copy of the repository, and returns its directory.
+func PreparePrDir(git GitRunner, localDir string, pr prs.PullRequest, m rootModel) (string, bool) {
+	if m.checkoutMode == checkoutModeWorktree {
+		path := WorktreePath(pr, m)
+		out, ok := AddWorktree(git, localDir, pr, path)
+		if !ok {
+			return out, false
+		}
+		return path, true
+	}
+
+	if out, ok := CheckoutPrBranch(git, localDir, pr); !ok {
+		return out, false
+	}
+	return localDir, true
+}
+
+func userCommand(envVars []string, fallback string) []string {
+	for _, envVar := range envVars {
+		if command := strings.Fields(os.Getenv(envVar)); len(command) > 0 {
+			return command
+		}
+	}
+	return []string{fallback}
+}
+
+func openInTerminal(pr prs.PullRequest, m rootModel, command []string) tea.Cmd {
+	localDir, teaCmd := CheckLocalDir(pr, m)
+	if localDir == "" {
+		return teaCmd
+	}
+	return RunJob(m, pr.Uid(), "prepare "+command[0], localDir, func(job jobContext) bool {
+		dir, ok := PreparePrDir(job.Git, localDir, pr, m)
+		if !ok {
+			job.Toast(NewErrorToast(dir))
+			return false
+		}
+		job.Toast(func() tea.Msg { return msgOpenInTerminal{dir, command} })
+		return true
+	})
+}
+
+func OpenEditor(pr prs.PullRequest, m rootModel) tea.Cmd {
+	return openInTerminal(pr, m, append(userCommand([]string{"VISUAL", "EDITOR"}, "vi"), "."))
+}
+
+func OpenShell(pr prs.PullRequest, m rootModel) tea.Cmd {
+	return openInTerminal(pr, m, userCommand([]string{"SHELL"}, "sh"))
+}
+
+// editInEditor suspends the program to edit the text in a temporary file with $VISUAL or $EDITOR.
+func editInEditor(text string) tea.Cmd {
+	file, err := os.CreateTemp("", "bb-description-*.md")
+	if err == nil {
+		_, err = file.WriteString(text)
+		file.Close()
+	}
+	if err != nil {
+		return func() tea.Msg { return model.MsgDescriptionEdited{Err: err} }
+	}
+
+	command := append(userCommand([]string{"VISUAL", "EDITOR"}, "vi"), file.Name())
+	cmd := exec.Command(command[0], command[1:]...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		defer os.Remove(file.Name())
+		if err != nil {
+			return model.MsgDescriptionEdited{Err: err}
+		}
+		data, err := os.ReadFile(file.Name())
+		return model.MsgDescriptionEdited{Text: string(data), Err: err}
+	})
+}
+
+// execInTerminal suspends the program until the command exits, then refreshes the pull requests.
+func execInTerminal(msg msgOpenInTerminal) tea.Cmd {
+	cmd := exec.Command(msg.command[0], msg.command[1:]...)
+	cmd.Dir = msg.dir
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if err != nil {
+			return model.MsgShowToast{Text: msg.command[0] + " failed: " + err.Error(), Style: errorToastStyle}
+		}
+		return model.MsgPrsLoading{}
+	})
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hejmsdz/bb/model"
+	"github.com/hejmsdz/bb/prs"
+)
+
+const (
+	checkoutModeBranch   = "branch"
+	checkoutModeWorktree = "worktree"
+)
+
+func WorktreePath(pr prs.PullRequest, m rootModel) string {
+	return filepath.Join(m.worktreesDir, filepath.FromSlash(pr.Repo), "pr-"+pr.Id)
+}
+
+// AddWorktree creates the worktree of the pull request at path, or reuses it if it already exists.
+func AddWorktree(git GitRunner, localDir string, pr prs.PullRequest, path string) (string, bool) {
+	remote, out, ok := FetchPrBranch(git, localDir, pr)
+	if !ok {
+		return out, false
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Sprintf("Reusing worktree %s (%s)", path, CompareWithPr(git, path, pr)), true
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err.Error(), false
+	}
+	if localBranchExists(git, localDir, pr.Branch) {
+		out, ok = git(localDir, "worktree", "add", path, pr.Branch)
+	} else {
+		out, ok = git(localDir, "worktree", "add", "--track", "-b", pr.Branch, path, remote+"/"+pr.Branch)
+	}
+	if !ok {
+		return out, false
+	}
+	return fmt.Sprintf("Created worktree %s (%s)", path, CompareWithPr(git, path, pr)), true
+}
+
+func CheckoutWorktree(pr prs.PullRequest, m rootModel) tea.Cmd {
+	localDir, teaCmd := CheckLocalDir(pr, m)
+	if localDir == "" {
+		return teaCmd
+	}
+	return RunJob(m, pr.Uid(), "worktree "+pr.Branch, localDir, func(job jobContext) bool {
+		out, ok := AddWorktree(job.Git, localDir, pr, WorktreePath(pr, m))
+		job.Toast(NewToast(out, ok))
+		job.Toast(LoadLocalStatuses(m))
+		return ok
+	})
+}
+
+// findStaleWorktrees lists worktrees created by bb for pull requests that are no longer open, by repository.
+// A pull request missing from the list may just no longer match it, so its state is checked before its worktree is listed.
+func findStaleWorktrees(m rootModel) map[string][]string {
+	openPrs := make(map[prs.Uid]bool)
+	for _, pr := range m.Prs.Prs {
+		openPrs[pr.Uid()] = true
+	}
+
+	stale := make(map[string][]string)
+	for repo, localDir := range m.localRepos {
+		out, ok := RunGitCommand(localDir, "worktree", "list", "--porcelain")
+		if !ok {
+			continue
+		}
+		repoWorktreesDir := filepath.Join(m.worktreesDir, filepath.FromSlash(repo))
+		for _, line := range strings.Split(out, "\n") {
+			path := strings.TrimPrefix(line, "worktree ")
+			if path == line || filepath.Dir(path) != repoWorktreesDir {
+				continue
+			}
+			id := strings.TrimPrefix(filepath.Base(path), "pr-")
+			if openPrs[fmt.Sprint(repo, "/", id)] {
+				continue
+			}
+			if pr, err := m.client.GetPullRequest(repo, id); err == nil && pr.State != prs.StateOpen {
+				stale[repo] = append(stale[repo], path)
+			}
+		}
+	}
+	return stale
+}
+
+func CleanupWorktrees(m rootModel) tea.Cmd {
+	if !m.Prs.IsLoaded() {
+		return NewErrorToast("Wait until pull requests are loaded")
+	}
+	if m.Prs.IsOffline() {
+		return NewErrorToast("Can't clean up worktrees while offline")
+	}
+	return func() tea.Msg {
+		stale := findStaleWorktrees(m)
+		count := 0
+		for _, paths := range stale {
+			count += len(paths)
+		}
+		if count == 0 {
+			return model.MsgShowToast{Text: "No worktrees to clean up", Style: infoToastStyle}
+		}
+
+		text := fmt.Sprintf("Remove %d worktrees of merged or declined pull requests?", count)
+		return model.Confirm(text, removeWorktrees(stale, m), nil)()
+	}
+}
+
+func removeWorktrees(stale map[string][]string, m rootModel) tea.Cmd {
+	cmds := make([]tea.Cmd, 0, len(stale))
+	for repo, paths := range stale {
+		localDir, paths := m.localRepos[repo], paths
+		cmds = append(cmds, RunJob(m, repo, "remove worktrees", localDir, func(job jobContext) bool {
+			allOk := true
+			for _, path := range paths {
+				out, ok := job.Git(localDir, "worktree", "remove", path)
+				if ok {
+					out = "Removed worktree " + path
+				}
+				job.Toast(NewToast(out, ok))
+				allOk = allOk && ok
+			}
+			job.Git(localDir, "worktree", "prune")
+			return allOk
+		}))
+	}
+	return tea.Batch(cmds...)
+}